@@ -1,34 +1,101 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	neturl "net/url"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
-	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
+	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/LoriKarikari/draftdeploy/internal/azure"
 	"github.com/LoriKarikari/draftdeploy/internal/compose"
+	"github.com/LoriKarikari/draftdeploy/internal/config"
+	"github.com/LoriKarikari/draftdeploy/internal/deployerr"
 	"github.com/LoriKarikari/draftdeploy/internal/github"
+	"github.com/LoriKarikari/draftdeploy/internal/metrics"
+	"github.com/LoriKarikari/draftdeploy/internal/naming"
+	"github.com/LoriKarikari/draftdeploy/internal/notify"
+	"golang.org/x/oauth2"
 )
 
 const (
-	defaultCPU      = 0.5
-	defaultMemoryGB = 0.5
-	deployTimeout   = 15 * time.Minute
-	teardownTimeout = 5 * time.Minute
+	defaultCPU              = 0.5
+	defaultMemoryGB         = 0.5
+	defaultDeployTimeout    = 15 * time.Minute
+	defaultTeardownTimeout  = 5 * time.Minute
+	reapTimeout             = 30 * time.Minute
+	listTimeout             = 5 * time.Minute
+	defaultLockWait         = 5 * time.Minute
+	defaultFailureLogLines  = 50
+	defaultReadinessTimeout = 2 * time.Minute
+	defaultReadinessPath    = "/"
+	defaultTTL              = 7 * 24 * time.Hour
+	defaultLocation         = "eastus"
+)
+
+// Exit codes let workflows react to the kind of failure. They are
+// documented in the README, so existing values must not change.
+const (
+	exitFailure = 1
+	exitConfig  = 2
+	exitAuth    = 3
+	exitQuota   = 4
+	exitTimeout = 5
 )
 
 type GitHubEvent struct {
 	Action      string `json:"action"`
 	Number      int    `json:"number"`
+	Ref         string `json:"ref"`
+	After       string `json:"after"`
+	Deleted     bool   `json:"deleted"`
 	PullRequest struct {
-		Number int `json:"number"`
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Merged bool   `json:"merged"`
+		Head   struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
 	} `json:"pull_request"`
+	Label struct {
+		Name string `json:"name"`
+	} `json:"label"`
+	Issue struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		// PullRequest is only present when the issue is a pull request.
+		PullRequest *struct{} `json:"pull_request"`
+	} `json:"issue"`
+	Comment struct {
+		Body              string `json:"body"`
+		AuthorAssociation string `json:"author_association"`
+		User              struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"comment"`
 	Repository struct {
 		Owner struct {
 			Login string `json:"login"`
@@ -37,304 +104,2306 @@ type GitHubEvent struct {
 	} `json:"repository"`
 }
 
+// Request is what a single run acts on, whether it came from a GitHub
+// Actions event file or from command-line flags.
+type Request struct {
+	Action   string
+	Owner    string
+	Repo     string
+	PRNumber int
+	Title    string
+	Branch   string
+	HeadSHA  string
+	Labels   []string
+	// Label is the label added or removed by a labeled or unlabeled action.
+	Label  string
+	Merged bool
+}
+
+// Actions of requests made with a slash command in a PR comment. They are
+// not gated by DD_TRIGGER_LABEL.
+const (
+	commandDeploy   = "deploy"
+	commandTeardown = "teardown"
+
+	defaultCommandPrefix = "/"
+)
+
+// commandAssociations are the author associations that may run slash
+// commands: people with write access to the repository.
+var commandAssociations = []string{"OWNER", "MEMBER", "COLLABORATOR"}
+
+type previewAction int
+
+const (
+	actionIgnore previewAction = iota
+	actionDeploy
+	actionTeardown
+)
+
+// resolveAction decides what a request does. With a trigger label, pull
+// requests only get a preview while they carry the label; branch previews
+// are not gated.
+func resolveAction(req Request, triggerLabel string) previewAction {
+	switch req.Action {
+	case "closed", commandTeardown:
+		return actionTeardown
+	case commandDeploy:
+		return actionDeploy
+	}
+
+	if triggerLabel == "" || req.Branch != "" {
+		switch req.Action {
+		case "opened", "synchronize", "reopened":
+			return actionDeploy
+		}
+		return actionIgnore
+	}
+
+	switch req.Action {
+	case "opened", "synchronize", "reopened":
+		if slices.ContainsFunc(req.Labels, func(l string) bool { return strings.EqualFold(l, triggerLabel) }) {
+			return actionDeploy
+		}
+	case "labeled":
+		if strings.EqualFold(req.Label, triggerLabel) {
+			return actionDeploy
+		}
+	case "unlabeled":
+		if strings.EqualFold(req.Label, triggerLabel) {
+			return actionTeardown
+		}
+	}
+	return actionIgnore
+}
+
 type deployConfig struct {
 	subscriptionID string
 	location       string
 	composeFile    string
-	githubToken    string
+	profiles       []string
+	githubAuth     oauth2.TokenSource
 	owner          string
 	repo           string
 	prNumber       int
+	branch         string
+	environment    string
 	resourceGroup  string
 	containerName  string
+	legacyName     string
 	dnsLabel       string
+	customDomain   string
+	labels         []string
+	extraTags      map[string]string
+	registry       *azure.RegistryCredential
+	storage        *azure.AzureFileStorage
+	headSHA        string
+	dryRun         bool
+	imageOverrides map[string]string
+	ingressService string
+	resources      serviceResources
+	secretKeys     []string
+	envFilter      compose.EnvFilter
+	ttl            time.Duration
+	startupGrace   time.Duration
+	logLines       int
+	comment        *template.Template
+	events         *eventSender
+	// deployer, if set, is used instead of one built from the environment.
+	deployer *azure.Deployer
+}
+
+// deployFailure carries the container logs captured before the failed
+// preview's resource group is cleaned up.
+type deployFailure struct {
+	err  error
+	logs []github.ContainerLog
+}
+
+func (f *deployFailure) Error() string { return f.err.Error() }
+
+func (f *deployFailure) Unwrap() error { return f.err }
+
+type deploymentOutput struct {
+	FQDN              string          `json:"fqdn"`
+	CustomDomain      string          `json:"custom_domain,omitempty"`
+	URL               string          `json:"url"`
+	ResourceGroup     string          `json:"resource_group"`
+	ContainerGroupID  string          `json:"container_group_id,omitempty"`
+	ProvisioningState string          `json:"provisioning_state,omitempty"`
+	IPAddress         string          `json:"ip_address,omitempty"`
+	Environment       string          `json:"environment"`
+	Services          []serviceOutput `json:"services"`
+	DeployTimeSeconds float64         `json:"deploy_time_seconds"`
+}
+
+type serviceOutput struct {
+	Name     string  `json:"name"`
+	Ports    []int32 `json:"ports"`
+	UDPPorts []int32 `json:"udp_ports,omitempty"`
+	Public   bool    `json:"public"`
+	Path     string  `json:"path,omitempty"`
+}
+
+type listedDeployment struct {
+	ResourceGroup string    `json:"resource_group"`
+	PR            int       `json:"pr"`
+	FQDN          string    `json:"fqdn,omitempty"`
+	Created       time.Time `json:"created"`
+	AgeSeconds    float64   `json:"age_seconds"`
+}
+
+type serviceResources struct {
+	cpu      float64
+	memoryGB float64
 }
 
 type teardownConfig struct {
 	subscriptionID string
-	githubToken    string
+	githubAuth     oauth2.TokenSource
 	owner          string
 	repo           string
 	prNumber       int
+	branch         string
+	environment    string
 	resourceGroup  string
+	containerName  string
+	storage        *azure.AzureFileStorage
+	dryRun         bool
+	merged         bool
+	mergedGrace    time.Duration
+	comment        *template.Template
+	events         *eventSender
 }
 
 func main() {
-	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+	logger, err := newLogger(os.Stdout, os.Getenv("DD_LOG_FORMAT"), os.Getenv("DD_LOG_LEVEL"))
+	if err != nil {
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+		slog.Warn("ignoring invalid logging configuration", "error", err)
+	} else {
+		slog.SetDefault(logger)
+	}
 
-	if err := run(); err != nil {
+	ctx, cancel := interruptContext()
+	err = run(ctx)
+	cancel()
+	if err != nil {
 		slog.Error("application failed", "error", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
-func run() error {
-	eventPath := os.Getenv("GITHUB_EVENT_PATH")
-	if eventPath == "" {
-		return fmt.Errorf("GITHUB_EVENT_PATH not set")
+// interruptContext is cancelled on the first SIGINT or SIGTERM, e.g. when
+// the runner cancels the job, so in-flight Azure calls return and deferred
+// cleanup runs. A second signal exits immediately.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			slog.Warn("received signal, cancelling and cleaning up", "signal", sig.String())
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+	return ctx, cancel
+}
+
+func newLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{}
+	if level = strings.TrimSpace(level); level != "" {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid DD_LOG_LEVEL value %q: must be debug, info, warn or error", level)
+		}
+		opts.Level = l
 	}
 
-	cleanPath := filepath.Clean(eventPath)
-	eventData, err := os.ReadFile(cleanPath)
-	if err != nil {
-		return fmt.Errorf("failed to read event file: %w", err)
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid DD_LOG_FORMAT value %q: must be json or text", format)
+	}
+}
+
+func run(ctx context.Context) error {
+	switch command() {
+	case "reap":
+		return reap(ctx)
+	case "list":
+		return list(ctx, os.Args[min(len(os.Args), 2):])
 	}
 
-	var event GitHubEvent
-	if err := json.Unmarshal(eventData, &event); err != nil {
-		return fmt.Errorf("failed to parse event: %w", err)
+	var req Request
+	if args := os.Args[min(len(os.Args), 1):]; len(args) > 0 {
+		r, err := requestFromFlags(args)
+		if err != nil {
+			return &deployerr.ConfigError{Err: err}
+		}
+		req = r
+	} else {
+		r, ok, err := requestFromEventFile()
+		if err != nil || !ok {
+			return err
+		}
+		req = r
 	}
 
-	prNumber := event.PullRequest.Number
-	if prNumber == 0 {
-		prNumber = event.Number
+	owner, repo := req.Owner, req.Repo
+	prNumber, branch := req.PRNumber, req.Branch
+	target := naming.Target{PRNumber: prNumber, Branch: branch}
+	if branch != "" && naming.BranchSlug(branch) == "" {
+		return &deployerr.ConfigError{Err: fmt.Errorf("branch %q has no usable characters for resource names", branch)}
 	}
 
-	owner := event.Repository.Owner.Login
-	repo := event.Repository.Name
+	if branch != "" {
+		slog.Info("processing push event",
+			"branch", branch,
+			"action", req.Action,
+			"owner", owner,
+			"repo", repo)
+	} else {
+		slog.Info("processing PR event",
+			"pr_number", prNumber,
+			"action", req.Action,
+			"owner", owner,
+			"repo", repo)
+	}
 
-	slog.Info("processing PR event",
-		"pr_number", prNumber,
-		"action", event.Action,
-		"owner", owner,
-		"repo", repo)
+	triggerLabel := strings.TrimSpace(os.Getenv("DD_TRIGGER_LABEL"))
+	action := resolveAction(req, triggerLabel)
+	if action == actionIgnore {
+		slog.Info("ignoring action", "action", req.Action, "trigger_label", triggerLabel)
+		return nil
+	}
 
 	subscriptionID := strings.TrimSpace(os.Getenv("AZURE_SUBSCRIPTION_ID"))
-	location := strings.TrimSpace(os.Getenv("AZURE_LOCATION"))
 	composeFile := strings.TrimSpace(os.Getenv("COMPOSE_FILE"))
-	githubToken := strings.TrimSpace(os.Getenv("GITHUB_TOKEN"))
 
-	if subscriptionID == "" {
-		return fmt.Errorf("AZURE_SUBSCRIPTION_ID not set")
+	fileCfg, err := config.Load(config.FileName)
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
 	}
-	if location == "" {
-		location = "eastus"
+
+	dryRun, err := parseBoolEnv("DRY_RUN")
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
 	}
-	if composeFile == "" {
-		composeFile = "docker-compose.yml"
+	if dryRun {
+		slog.Info("dry run enabled, no Azure or GitHub resources will be changed")
 	}
 
-	resourceGroup, err := sanitizeResourceGroupName(owner, repo, prNumber)
+	if subscriptionID == "" && !dryRun {
+		return &deployerr.ConfigError{Err: fmt.Errorf("AZURE_SUBSCRIPTION_ID not set")}
+	}
+	location, err := resolveLocation("", os.Getenv("AZURE_LOCATION"), fileCfg.Location)
 	if err != nil {
-		return fmt.Errorf("invalid resource group name: %w", err)
+		return &deployerr.ConfigError{Err: err}
 	}
-	containerName := fmt.Sprintf("dd-pr%d", prNumber)
-	dnsLabel, err := sanitizeDNSLabel(owner, repo, prNumber)
+
+	githubAuth, err := githubTokenSourceFromEnv()
 	if err != nil {
-		return fmt.Errorf("invalid DNS label: %w", err)
+		return &deployerr.ConfigError{Err: err}
 	}
 
-	switch event.Action {
-	case "opened", "synchronize", "reopened":
-		ctx, cancel := context.WithTimeout(context.Background(), deployTimeout)
+	if action == actionDeploy {
+		allowed := splitList(os.Getenv("DD_ALLOWED_REPOS"))
+		if err := validateRepoPatterns(allowed); err != nil {
+			return &deployerr.ConfigError{Err: err}
+		}
+		if !isRepoAllowed(owner, repo, allowed) {
+			slog.Warn("repository is not in DD_ALLOWED_REPOS, not deploying", "repository", owner+"/"+repo)
+			if githubAuth != nil && !dryRun && prNumber != 0 {
+				ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+				defer cancel()
+				commenter := github.NewCommenterWithTokenSource(githubAuth, owner, repo)
+				if err := commenter.PostSkipped(ctx, prNumber, "This repository is not allowed to create preview environments. Ask your platform team to add it to `DD_ALLOWED_REPOS`."); err != nil {
+					slog.Warn("failed to post comment", "error", err)
+				}
+			}
+			return nil
+		}
+	}
+
+	registry, err := registryCredentialFromEnv()
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+
+	storage, err := storageFromEnv()
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+
+	envOverrides, err := parseImageOverrides(os.Getenv("DD_IMAGE_OVERRIDES"))
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+	imageOverrides := make(map[string]string, len(fileCfg.ImageOverrides)+len(envOverrides))
+	maps.Copy(imageOverrides, fileCfg.ImageOverrides)
+	maps.Copy(imageOverrides, envOverrides)
+
+	secretKeys := splitList(os.Getenv("DD_SECRET_KEYS"))
+
+	extraTags, err := parseExtraTags(os.Getenv("DD_EXTRA_TAGS"))
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+
+	ingressService := strings.TrimSpace(os.Getenv("DD_INGRESS_SERVICE"))
+	if ingressService == "" {
+		ingressService = fileCfg.IngressService
+	}
+
+	resources := serviceResources{cpu: defaultCPU, memoryGB: defaultMemoryGB}
+	if fileCfg.CPU > 0 {
+		resources.cpu = fileCfg.CPU
+	}
+	if fileCfg.MemoryGB > 0 {
+		resources.memoryGB = fileCfg.MemoryGB
+	}
+	resources, err = parseResources(os.Getenv("DD_CPU"), os.Getenv("DD_MEMORY_GB"), resources)
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+
+	ttl, err := parseDurationEnv("DD_TTL", defaultTTL)
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+
+	startupGrace, err := parseDurationEnv("DD_STARTUP_GRACE", 0)
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+
+	mergedGrace, err := parseDurationEnv("DD_MERGED_GRACE", 0)
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+
+	logLines := defaultFailureLogLines
+	if value := strings.TrimSpace(os.Getenv("DD_FAILURE_LOG_LINES")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return &deployerr.ConfigError{Err: fmt.Errorf("invalid DD_FAILURE_LOG_LINES value %q: must be a non-negative integer", value)}
+		}
+		logLines = n
+	}
+
+	envFilter, err := envFilterFromEnv()
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+
+	commentTemplate, err := commentTemplateFromEnv()
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+
+	notifiers, err := notifiersFromEnv(dryRun)
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+
+	names, err := nameSchemeFromEnv()
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+	resourceGroup, err := names.ResourceGroupName(owner, repo, target)
+	if err != nil {
+		return &deployerr.ConfigError{Err: fmt.Errorf("invalid resource group name: %w", err)}
+	}
+	containerName := names.ContainerGroupName(owner, repo, target)
+	dnsLabel, err := names.DNSLabel(owner, repo, target)
+	if err != nil {
+		return &deployerr.ConfigError{Err: fmt.Errorf("invalid DNS label: %w", err)}
+	}
+
+	var customDomain string
+	if template := strings.TrimSpace(os.Getenv("DD_CUSTOM_DOMAIN")); template != "" {
+		customDomain, err = naming.CustomDomain(template, target)
+		if err != nil {
+			return &deployerr.ConfigError{Err: fmt.Errorf("invalid DD_CUSTOM_DOMAIN: %w", err)}
+		}
+	}
+
+	events := newEventSender(notifiers, notify.Event{
+		Repository:    owner + "/" + repo,
+		PR:            prNumber,
+		Title:         req.Title,
+		Branch:        branch,
+		ResourceGroup: resourceGroup,
+	})
+
+	switch action {
+	case actionDeploy:
+		timeout := timeoutFromEnv("DD_DEPLOY_TIMEOUT", defaultDeployTimeout)
+		slog.Info("starting deploy", "timeout", timeout.String())
+		ctx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
-		return deploy(ctx, deployConfig{
+		cfg := deployConfig{
 			subscriptionID: subscriptionID,
 			location:       location,
 			composeFile:    composeFile,
-			githubToken:    githubToken,
+			profiles:       splitList(os.Getenv("DD_COMPOSE_PROFILES")),
+			githubAuth:     githubAuth,
 			owner:          owner,
 			repo:           repo,
 			prNumber:       prNumber,
+			branch:         branch,
+			environment:    environmentName(target),
 			resourceGroup:  resourceGroup,
 			containerName:  containerName,
+			legacyName:     names.LegacyContainerGroupName(target),
 			dnsLabel:       dnsLabel,
-		})
-	case "closed":
-		ctx, cancel := context.WithTimeout(context.Background(), teardownTimeout)
+			customDomain:   customDomain,
+			labels:         req.Labels,
+			extraTags:      extraTags,
+			registry:       registry,
+			storage:        storage,
+			headSHA:        req.HeadSHA,
+			dryRun:         dryRun,
+			imageOverrides: imageOverrides,
+			ingressService: ingressService,
+			resources:      resources,
+			secretKeys:     secretKeys,
+			envFilter:      envFilter,
+			ttl:            ttl,
+			startupGrace:   startupGrace,
+			logLines:       logLines,
+			comment:        commentTemplate,
+			events:         events,
+		}
+		start := time.Now()
+		err := deploy(ctx, cfg)
+		writeMetrics(metricLabels(owner, repo, prNumber, branch),
+			metrics.Duration(metrics.DeployDuration, time.Since(start)),
+			metrics.Success(err == nil))
+		if err != nil {
+			reportDeployFailure(cfg, err, time.Since(start))
+			return err
+		}
+		return nil
+	case actionTeardown:
+		timeout := timeoutFromEnv("DD_TEARDOWN_TIMEOUT", defaultTeardownTimeout)
+		slog.Info("starting teardown", "timeout", timeout.String())
+		ctx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
-		return teardown(ctx, teardownConfig{
+		start := time.Now()
+		err := teardown(ctx, teardownConfig{
 			subscriptionID: subscriptionID,
-			githubToken:    githubToken,
+			githubAuth:     githubAuth,
 			owner:          owner,
 			repo:           repo,
 			prNumber:       prNumber,
+			branch:         branch,
+			environment:    environmentName(target),
 			resourceGroup:  resourceGroup,
+			containerName:  containerName,
+			storage:        storage,
+			dryRun:         dryRun,
+			merged:         req.Merged,
+			mergedGrace:    mergedGrace,
+			comment:        commentTemplate,
+			events:         events,
 		})
-	default:
-		slog.Info("ignoring action", "action", event.Action)
-		return nil
+		writeMetrics(metricLabels(owner, repo, prNumber, branch), metrics.Duration(metrics.TeardownDuration, time.Since(start)))
+		return err
 	}
+	return nil
 }
 
-func sanitizeResourceGroupName(owner, repo string, prNumber int) (string, error) {
-	re := regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
-	cleanOwner := re.ReplaceAllString(owner, "-")
-	cleanRepo := re.ReplaceAllString(repo, "-")
+// writeMetrics writes DD_METRICS_FILE when it is set. Failures are only
+// logged so metrics never fail a run.
+func writeMetrics(labels map[string]string, samples ...metrics.Sample) {
+	path := strings.TrimSpace(os.Getenv("DD_METRICS_FILE"))
+	if path == "" {
+		return
+	}
+	if err := metrics.WriteFile(path, labels, samples...); err != nil {
+		slog.Warn("failed to write metrics file", "path", path, "error", err)
+	}
+}
 
-	name := fmt.Sprintf("draftdeploy-%s-%s-pr%d", cleanOwner, cleanRepo, prNumber)
-	if len(name) > 90 {
-		return "", fmt.Errorf("resource group name too long: %d chars (max 90)", len(name))
+func metricLabels(owner, repo string, prNumber int, branch string) map[string]string {
+	labels := map[string]string{"owner": owner, "repo": repo}
+	if branch != "" {
+		labels["branch"] = branch
+	} else {
+		labels["pr"] = strconv.Itoa(prNumber)
 	}
-	return name, nil
+	return labels
 }
 
-func sanitizeDNSLabel(owner, repo string, prNumber int) (string, error) {
-	re := regexp.MustCompile(`[^a-z0-9-]`)
-	cleanOwner := re.ReplaceAllString(strings.ToLower(owner), "-")
-	cleanRepo := re.ReplaceAllString(strings.ToLower(repo), "-")
+// resolveLocation applies the location precedence: the compose
+// draftdeploy.location label, then AZURE_LOCATION, then .draftdeploy.yml,
+// then eastus.
+func resolveLocation(label, env, file string) (string, error) {
+	location := cmp.Or(strings.TrimSpace(label), strings.TrimSpace(env), strings.TrimSpace(file), defaultLocation)
+	if err := azure.ValidateLocation(location); err != nil {
+		return "", err
+	}
+	return location, nil
+}
 
-	label := fmt.Sprintf("dd-%s-%s-pr%d", cleanOwner, cleanRepo, prNumber)
-	label = strings.Trim(label, "-")
+func nameSchemeFromEnv() (naming.Scheme, error) {
+	scheme := naming.DefaultScheme()
 
-	if len(label) < 3 {
-		return "", fmt.Errorf("DNS label too short: %d chars (min 3)", len(label))
+	if prefix := strings.TrimSpace(os.Getenv("DD_RG_PREFIX")); prefix != "" {
+		scheme.ResourceGroupPrefix = prefix
+		scheme.ShortPrefix = prefix
 	}
-	if len(label) > 63 {
-		label = fmt.Sprintf("dd-pr%d", prNumber)
+	if template := strings.TrimSpace(os.Getenv("DD_RG_TEMPLATE")); template != "" {
+		scheme.Template = template
 	}
-	return label, nil
+	if err := scheme.Validate(); err != nil {
+		return naming.Scheme{}, fmt.Errorf("invalid DD_RG_TEMPLATE: %w", err)
+	}
+	return scheme, nil
 }
 
-func setGitHubOutput(name, value string) error {
-	outputFile := os.Getenv("GITHUB_OUTPUT")
-	if outputFile == "" {
-		slog.Info("github output", name, value)
-		return nil
+func environmentName(target naming.Target) string {
+	if target.Branch != "" {
+		return github.BranchEnvironmentName(naming.BranchID(target.Branch))
 	}
+	return github.EnvironmentName(target.PRNumber)
+}
 
-	// #nosec G304 G302 -- GITHUB_OUTPUT is a trusted path from GitHub Actions runtime
-	f, err := os.OpenFile(outputFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+func requestFromEventFile() (Request, bool, error) {
+	eventPath := os.Getenv("GITHUB_EVENT_PATH")
+	if eventPath == "" {
+		return Request{}, false, fmt.Errorf("GITHUB_EVENT_PATH not set")
+	}
+
+	cleanPath := filepath.Clean(eventPath)
+	eventData, err := os.ReadFile(cleanPath)
 	if err != nil {
-		return fmt.Errorf("failed to open GITHUB_OUTPUT: %w", err)
+		return Request{}, false, fmt.Errorf("failed to read event file: %w", err)
 	}
-	defer f.Close()
 
-	if _, err := fmt.Fprintf(f, "%s=%s\n", name, value); err != nil {
-		return fmt.Errorf("failed to write output: %w", err)
+	var event GitHubEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return Request{}, false, fmt.Errorf("failed to parse event: %w", err)
 	}
-	return nil
-}
 
-func parseComposeServices(project *compose.Project) ([]azure.ContainerConfig, []github.ServiceInfo) {
-	var containers []azure.ContainerConfig
-	var services []github.ServiceInfo
+	if os.Getenv("GITHUB_EVENT_NAME") == "issue_comment" {
+		prefix := os.Getenv("DD_COMMAND_PREFIX")
+		if strings.TrimSpace(prefix) == "" {
+			prefix = defaultCommandPrefix
+		}
+		req, ok := requestFromComment(event, prefix, splitList(os.Getenv("DD_COMMAND_USERS")))
+		return req, ok, nil
+	}
 
-	for _, name := range project.GetServiceNames() {
-		image := project.GetServiceImage(name)
-		if image == "" {
-			slog.Info("skipping service with build config", "service", name)
-			continue
+	req := Request{
+		Action:   event.Action,
+		Owner:    event.Repository.Owner.Login,
+		Repo:     event.Repository.Name,
+		PRNumber: event.PullRequest.Number,
+		Title:    event.PullRequest.Title,
+		HeadSHA:  event.PullRequest.Head.SHA,
+		Label:    event.Label.Name,
+		Merged:   event.PullRequest.Merged,
+		Labels:   make([]string, 0, len(event.PullRequest.Labels)),
+	}
+	if req.PRNumber == 0 {
+		req.PRNumber = event.Number
+	}
+	for _, l := range event.PullRequest.Labels {
+		req.Labels = append(req.Labels, l.Name)
+	}
+
+	if os.Getenv("GITHUB_EVENT_NAME") == "push" {
+		name, ok := strings.CutPrefix(event.Ref, "refs/heads/")
+		if !ok {
+			slog.Info("ignoring push to non-branch ref", "ref", event.Ref)
+			return Request{}, false, nil
+		}
+		req.Branch = name
+		req.HeadSHA = event.After
+		req.Action = "synchronize"
+		if event.Deleted {
+			req.Action = "closed"
 		}
+	}
+	return req, true, nil
+}
 
-		ports := project.GetExposedPorts(name)
+// requestFromComment turns a "/deploy" or "/teardown" comment on a pull
+// request into a request. Comments without a command, on plain issues or
+// from users without write access or a DD_COMMAND_USERS entry are ignored.
+func requestFromComment(event GitHubEvent, prefix string, users []string) (Request, bool) {
+	if event.Action != "created" || event.Issue.PullRequest == nil {
+		slog.Info("ignoring comment that is not a new pull request comment", "action", event.Action, "issue", event.Issue.Number)
+		return Request{}, false
+	}
 
-		containers = append(containers, azure.ContainerConfig{
-			Name:     name,
-			Image:    image,
-			Ports:    ports,
-			CPU:      defaultCPU,
-			MemoryGB: defaultMemoryGB,
-		})
+	action, ok := parseSlashCommand(event.Comment.Body, prefix)
+	if !ok {
+		return Request{}, false
+	}
 
-		services = append(services, github.ServiceInfo{
-			Name:  name,
-			Ports: ports,
-		})
+	author := event.Comment.User.Login
+	if !slices.Contains(commandAssociations, event.Comment.AuthorAssociation) &&
+		!slices.ContainsFunc(users, func(u string) bool { return strings.EqualFold(u, author) }) {
+		slog.Warn("ignoring command from user without write access", "user", author, "association", event.Comment.AuthorAssociation, "command", action)
+		return Request{}, false
 	}
 
-	return containers, services
+	req := Request{
+		Action:   action,
+		Owner:    event.Repository.Owner.Login,
+		Repo:     event.Repository.Name,
+		PRNumber: event.Issue.Number,
+		Title:    event.Issue.Title,
+		Labels:   make([]string, 0, len(event.Issue.Labels)),
+	}
+	for _, l := range event.Issue.Labels {
+		req.Labels = append(req.Labels, l.Name)
+	}
+	slog.Info("running command from comment", "user", author, "command", action, "pr_number", req.PRNumber)
+	return req, true
 }
 
-func deploy(ctx context.Context, cfg deployConfig) error {
-	start := time.Now()
+// parseSlashCommand returns the command on the first line of a comment
+// body, e.g. "deploy" for "/deploy please".
+func parseSlashCommand(body, prefix string) (string, bool) {
+	line, _, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	rest, ok := cutPrefixFold(strings.TrimSpace(line), prefix)
+	if !ok {
+		return "", false
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", false
+	}
+	switch command := strings.ToLower(fields[0]); command {
+	case commandDeploy, commandTeardown:
+		return command, true
+	}
+	return "", false
+}
 
-	project, err := compose.Load(cfg.composeFile)
-	if err != nil {
-		return fmt.Errorf("failed to load compose file: %w", err)
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
 	}
+	return s[len(prefix):], true
+}
 
-	containers, services := parseComposeServices(project)
-	if len(containers) == 0 {
-		return fmt.Errorf("no deployable services found (all have build configs)")
+func requestFromFlags(args []string) (Request, error) {
+	flags := flag.NewFlagSet("draftdeploy", flag.ContinueOnError)
+	action := flags.String("action", "", "what to do: opened, synchronize or reopened deploy, closed tears down")
+	owner := flags.String("owner", "", "repository owner")
+	repo := flags.String("repo", "", "repository name")
+	pr := flags.Int("pr", 0, "pull request number")
+	branch := flags.String("branch", "", "branch to preview instead of a pull request")
+	sha := flags.String("sha", "", "commit SHA for status checks and branch comments")
+	title := flags.String("title", "", "pull request title for notifications")
+	labels := flags.String("labels", "", "comma-separated pull request labels")
+	merged := flags.Bool("merged", false, "with --action closed, the pull request was merged")
+	label := flags.String("label", "", "with --action labeled or unlabeled, the label that changed")
+	if err := flags.Parse(args); err != nil {
+		return Request{}, err
+	}
+	if flags.NArg() > 0 {
+		return Request{}, fmt.Errorf("unknown command %q", flags.Arg(0))
 	}
 
-	cred, err := azure.NewCredential()
-	if err != nil {
-		return fmt.Errorf("failed to create Azure credential: %w", err)
+	req := Request{
+		Action:   strings.TrimSpace(*action),
+		Owner:    strings.TrimSpace(*owner),
+		Repo:     strings.TrimSpace(*repo),
+		PRNumber: *pr,
+		Title:    strings.TrimSpace(*title),
+		Branch:   strings.TrimSpace(*branch),
+		HeadSHA:  strings.TrimSpace(*sha),
+		Labels:   splitList(*labels),
+		Label:    strings.TrimSpace(*label),
+		Merged:   *merged,
+	}
+	switch {
+	case req.Action == "":
+		return Request{}, fmt.Errorf("--action is required")
+	case req.Owner == "" || req.Repo == "":
+		return Request{}, fmt.Errorf("--owner and --repo are required")
+	case req.PRNumber < 0:
+		return Request{}, fmt.Errorf("invalid --pr value %d", req.PRNumber)
+	case (req.PRNumber > 0) == (req.Branch != ""):
+		return Request{}, fmt.Errorf("exactly one of --pr or --branch is required")
 	}
+	return req, nil
+}
 
-	deployer, err := azure.NewDeployer(cred, cfg.subscriptionID)
-	if err != nil {
-		return fmt.Errorf("failed to create deployer: %w", err)
+func command() string {
+	if len(os.Args) > 1 {
+		return os.Args[1]
 	}
+	return strings.TrimSpace(os.Getenv("DD_COMMAND"))
+}
 
-	var deploymentSucceeded bool
-	defer func() {
-		if !deploymentSucceeded {
-			cleanupCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-			defer cancel()
+func parseDurationEnv(name string, fallback time.Duration) (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return fallback, nil
+	}
 
-			slog.Warn("deployment failed, attempting cleanup", "resource_group", cfg.resourceGroup)
-			if err := deployer.DeleteResourceGroup(cleanupCtx, cfg.resourceGroup); err != nil {
-				slog.Error("failed to cleanup resource group", "error", err)
-			}
-		}
-	}()
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value %q: %w", name, value, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s value %q: must be positive", name, value)
+	}
+	return d, nil
+}
 
-	slog.Info("deploying to Azure", "resource_group", cfg.resourceGroup, "location", cfg.location)
-	fqdn, err := deployer.Deploy(ctx, azure.DeployConfig{
-		ResourceGroup: cfg.resourceGroup,
-		Name:          cfg.containerName,
-		Location:      cfg.location,
-		Containers:    containers,
-		DNSNameLabel:  cfg.dnsLabel,
-	})
+func timeoutFromEnv(name string, fallback time.Duration) time.Duration {
+	timeout, err := parseDurationEnv(name, fallback)
 	if err != nil {
-		return fmt.Errorf("failed to deploy: %w", err)
+		slog.Warn("ignoring invalid timeout, using default", "error", err, "default", fallback.String())
+		return fallback
 	}
+	return timeout
+}
 
-	deployTime := time.Since(start)
-	slog.Info("deployment complete",
-		"fqdn", fqdn,
-		"deploy_time", deployTime.Round(time.Second))
-
-	if cfg.githubToken != "" {
-		commenter := github.NewCommenter(cfg.githubToken, cfg.owner, cfg.repo)
-		if err := commenter.PostDeployment(ctx, cfg.prNumber, github.DeploymentInfo{
-			FQDN:       fqdn,
-			Services:   services,
-			DeployTime: deployTime,
-		}); err != nil {
-			slog.Warn("failed to post comment", "error", err)
-		}
+func newDeployer(subscriptionID string) (*azure.Deployer, error) {
+	retryPolicy, err := retryPolicyFromEnv()
+	if err != nil {
+		return nil, err
 	}
 
-	if err := setGitHubOutput("url", fmt.Sprintf("http://%s", fqdn)); err != nil {
-		slog.Warn("failed to set url output", "error", err)
+	cred, err := azure.NewCredential()
+	if err != nil {
+		return nil, err
 	}
-	if err := setGitHubOutput("resource-group", cfg.resourceGroup); err != nil {
-		slog.Warn("failed to set resource-group output", "error", err)
+
+	deployer, err := azure.NewDeployer(cred, subscriptionID, retryPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create deployer: %w", err)
 	}
+	return deployer, nil
+}
 
-	deploymentSucceeded = true
-	return nil
+func costRatesFromEnv() azure.CostRates {
+	rates := azure.DefaultCostRates()
+	rates.VCPUSecond = rateFromEnv("DD_COST_VCPU_SECOND", rates.VCPUSecond)
+	rates.GBSecond = rateFromEnv("DD_COST_GB_SECOND", rates.GBSecond)
+	return rates
 }
 
-func teardown(ctx context.Context, cfg teardownConfig) error {
-	cred, err := azure.NewCredential()
-	if err != nil {
-		return fmt.Errorf("failed to create Azure credential: %w", err)
+func rateFromEnv(name string, fallback float64) float64 {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return fallback
 	}
 
-	deployer, err := azure.NewDeployer(cred, cfg.subscriptionID)
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate < 0 {
+		slog.Warn("ignoring invalid cost rate, using default", "name", name, "value", value, "default", fallback)
+		return fallback
+	}
+	return rate
+}
+
+func retryPolicyFromEnv() (azure.RetryPolicy, error) {
+	policy := azure.DefaultRetryPolicy()
+
+	maxElapsed, err := parseDurationEnv("DD_RETRY_MAX_ELAPSED", policy.MaxElapsedTime)
 	if err != nil {
-		return fmt.Errorf("failed to create deployer: %w", err)
+		return policy, err
 	}
+	policy.MaxElapsedTime = maxElapsed
 
-	slog.Info("tearing down resource group", "resource_group", cfg.resourceGroup)
-	if err := deployer.DeleteResourceGroup(ctx, cfg.resourceGroup); err != nil {
-		return fmt.Errorf("failed to delete resource group: %w", err)
+	initial, err := parseDurationEnv("DD_RETRY_INITIAL_INTERVAL", policy.InitialInterval)
+	if err != nil {
+		return policy, err
 	}
+	policy.InitialInterval = initial
 
-	slog.Info("teardown complete")
+	if value := strings.TrimSpace(os.Getenv("DD_RETRY_MULTIPLIER")); value != "" {
+		multiplier, err := strconv.ParseFloat(value, 64)
+		if err != nil || multiplier < 1 {
+			return policy, fmt.Errorf("invalid DD_RETRY_MULTIPLIER value %q: must be a number >= 1", value)
+		}
+		policy.Multiplier = multiplier
+	}
 
-	if cfg.githubToken != "" {
-		commenter := github.NewCommenter(cfg.githubToken, cfg.owner, cfg.repo)
-		if err := commenter.PostTeardown(ctx, cfg.prNumber, github.DeploymentInfo{}); err != nil {
-			slog.Warn("failed to post teardown comment", "error", err)
+	if value := strings.TrimSpace(os.Getenv("DD_RETRY_JITTER")); value != "" {
+		jitter, err := strconv.ParseFloat(value, 64)
+		if err != nil || jitter <= 0 || jitter > 1 {
+			return policy, fmt.Errorf("invalid DD_RETRY_JITTER value %q: must be a number > 0 and <= 1", value)
 		}
+		policy.RandomizationFactor = jitter
 	}
 
-	return nil
+	policy.Notify = func(err error, next time.Duration) {
+		slog.Debug("retrying Azure operation", "error", err, "next_attempt_in", next.Round(time.Millisecond).String())
+	}
+	return policy, nil
+}
+
+func parseBoolEnv(name string) (bool, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value %q: %w", name, value, err)
+	}
+	return b, nil
+}
+
+func githubTokenSourceFromEnv() (oauth2.TokenSource, error) {
+	appID := strings.TrimSpace(os.Getenv("GITHUB_APP_ID"))
+	installationID := strings.TrimSpace(os.Getenv("GITHUB_APP_INSTALLATION_ID"))
+	privateKey := os.Getenv("GITHUB_APP_PRIVATE_KEY")
+
+	if appID == "" && installationID == "" && privateKey == "" {
+		if token := strings.TrimSpace(os.Getenv("GITHUB_TOKEN")); token != "" {
+			return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), nil
+		}
+		return nil, nil
+	}
+	if appID == "" || installationID == "" || privateKey == "" {
+		return nil, fmt.Errorf("GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PRIVATE_KEY must be set together")
+	}
+
+	id, err := strconv.ParseInt(appID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_APP_ID %q: %w", appID, err)
+	}
+	installation, err := strconv.ParseInt(installationID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_APP_INSTALLATION_ID %q: %w", installationID, err)
+	}
+
+	slog.Info("authenticating to GitHub as an app installation", "app_id", id, "installation_id", installation)
+	return github.NewInstallationTokenSource(id, installation, []byte(privateKey))
+}
+
+func registryCredentialFromEnv() (*azure.RegistryCredential, error) {
+	server := strings.TrimSpace(os.Getenv("REGISTRY_SERVER"))
+	username := strings.TrimSpace(os.Getenv("REGISTRY_USERNAME"))
+	password := os.Getenv("REGISTRY_PASSWORD")
+
+	if server == "" && username == "" && password == "" {
+		return nil, nil
+	}
+	if server == "" || username == "" || password == "" {
+		return nil, fmt.Errorf("REGISTRY_SERVER, REGISTRY_USERNAME and REGISTRY_PASSWORD must be set together")
+	}
+
+	return &azure.RegistryCredential{
+		Server:   server,
+		Username: username,
+		Password: password,
+	}, nil
+}
+
+func storageFromEnv() (*azure.AzureFileStorage, error) {
+	name := strings.TrimSpace(os.Getenv("DD_STORAGE_ACCOUNT_NAME"))
+	key := os.Getenv("DD_STORAGE_ACCOUNT_KEY")
+
+	if name == "" && key == "" {
+		return nil, nil
+	}
+	if name == "" || key == "" {
+		return nil, fmt.Errorf("DD_STORAGE_ACCOUNT_NAME and DD_STORAGE_ACCOUNT_KEY must be set together")
+	}
+
+	return &azure.AzureFileStorage{AccountName: name, AccountKey: key}, nil
+}
+
+func setGitHubOutput(name, value string) error {
+	outputFile := os.Getenv("GITHUB_OUTPUT")
+	if outputFile == "" {
+		slog.Info("github output", name, value)
+		return nil
+	}
+
+	// #nosec G304 G302 -- GITHUB_OUTPUT is a trusted path from GitHub Actions runtime
+	f, err := os.OpenFile(outputFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open GITHUB_OUTPUT: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "%s=%s\n", name, value); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// writeStepSummary appends markdown to the job summary GitHub Actions shows
+// on the run page, so the preview details are visible without PR comments.
+func writeStepSummary(markdown string) error {
+	summaryFile := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryFile == "" {
+		return nil
+	}
+
+	// #nosec G304 G302 -- GITHUB_STEP_SUMMARY is a trusted path from GitHub Actions runtime
+	f, err := os.OpenFile(summaryFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open GITHUB_STEP_SUMMARY: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintln(f, markdown); err != nil {
+		return fmt.Errorf("failed to write step summary: %w", err)
+	}
+	return nil
+}
+
+// isRepoAllowed matches owner/repo against patterns such as acme/api or
+// acme/*, ignoring case. An empty list allows every repository.
+func isRepoAllowed(owner, repo string, allow []string) bool {
+	if len(allow) == 0 {
+		return true
+	}
+	name := strings.ToLower(owner + "/" + repo)
+	for _, pattern := range allow {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func validateRepoPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil || strings.Count(pattern, "/") != 1 {
+			return fmt.Errorf("invalid DD_ALLOWED_REPOS entry %q: expected owner/repo, owner/* or another glob", pattern)
+		}
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envFilterFromEnv reads DD_FORWARD_ENV, DD_ENV_ALLOW and DD_ENV_DENY.
+// DD_ENV_DENY falls back to compose.DefaultEnvDeny and "none" turns the
+// deny list off.
+func envFilterFromEnv() (compose.EnvFilter, error) {
+	forward, err := parseBoolEnv("DD_FORWARD_ENV")
+	if err != nil {
+		return compose.EnvFilter{}, err
+	}
+	filter := compose.EnvFilter{
+		Forward: forward,
+		Allow:   splitList(os.Getenv("DD_ENV_ALLOW")),
+		Deny:    compose.DefaultEnvDeny,
+	}
+	switch deny := strings.TrimSpace(os.Getenv("DD_ENV_DENY")); deny {
+	case "":
+	case "none":
+		filter.Deny = nil
+	default:
+		filter.Deny = splitList(deny)
+	}
+	if err := filter.Validate(); err != nil {
+		return compose.EnvFilter{}, fmt.Errorf("invalid DD_ENV_ALLOW or DD_ENV_DENY: %w", err)
+	}
+	return filter, nil
+}
+
+// commentTemplateFromEnv loads the text/template named by
+// DD_COMMENT_TEMPLATE. A nil template keeps the built-in comments.
+func commentTemplateFromEnv() (*template.Template, error) {
+	path := strings.TrimSpace(os.Getenv("DD_COMMENT_TEMPLATE"))
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DD_COMMENT_TEMPLATE: %w", err)
+	}
+	tmpl, err := github.ParseCommentTemplate(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DD_COMMENT_TEMPLATE %s: %w", path, err)
+	}
+	return tmpl, nil
+}
+
+// parseExtraTags reads DD_EXTRA_TAGS, a comma-separated list of key=value
+// tags added to the preview's resources.
+func parseExtraTags(value string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, entry := range splitList(value) {
+		key, val, ok := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid DD_EXTRA_TAGS entry %q (expected key=value)", entry)
+		}
+		val = strings.TrimSpace(val)
+		if err := azure.ValidateTag(key, val); err != nil {
+			return nil, fmt.Errorf("invalid DD_EXTRA_TAGS entry %q: %w", entry, err)
+		}
+		// Lock and label tags use this prefix, and Azure tag names are
+		// case-insensitive.
+		if strings.HasPrefix(strings.ToLower(key), "draftdeploy-") {
+			return nil, fmt.Errorf("invalid DD_EXTRA_TAGS entry %q: tag name uses the prefix %q reserved for DraftDeploy", entry, "draftdeploy-")
+		}
+		tags[key] = val
+	}
+	if len(tags) > azure.MaxExtraTags {
+		return nil, fmt.Errorf("DD_EXTRA_TAGS has %d tags, at most %d are allowed", len(tags), azure.MaxExtraTags)
+	}
+	return tags, nil
+}
+
+// parseResources applies DD_CPU and DD_MEMORY_GB on top of fallback and
+// checks that every container can get the pair from Container Instances.
+func parseResources(cpuValue, memoryValue string, fallback serviceResources) (serviceResources, error) {
+	resources := fallback
+	if value := strings.TrimSpace(cpuValue); value != "" {
+		cpu, err := strconv.ParseFloat(value, 64)
+		if err != nil || cpu <= 0 {
+			return serviceResources{}, fmt.Errorf("invalid DD_CPU value %q: must be a positive number of vCPUs", value)
+		}
+		resources.cpu = cpu
+	}
+	if value := strings.TrimSpace(memoryValue); value != "" {
+		memoryGB, err := strconv.ParseFloat(value, 64)
+		if err != nil || memoryGB <= 0 {
+			return serviceResources{}, fmt.Errorf("invalid DD_MEMORY_GB value %q: must be a positive number of GB", value)
+		}
+		resources.memoryGB = memoryGB
+	}
+
+	cpu, memoryGB, err := azure.NormalizeResources(resources.cpu, resources.memoryGB)
+	if err != nil {
+		return serviceResources{}, fmt.Errorf("invalid container resources from DD_CPU, DD_MEMORY_GB or .draftdeploy.yml: %w", err)
+	}
+	return serviceResources{cpu: cpu, memoryGB: memoryGB}, nil
+}
+
+func parseImageOverrides(value string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, entry := range splitList(value) {
+		service, image, ok := strings.Cut(entry, "=")
+		service = strings.TrimSpace(service)
+		image = strings.TrimSpace(image)
+		if !ok || service == "" || image == "" {
+			return nil, fmt.Errorf("invalid DD_IMAGE_OVERRIDES entry %q (expected service=image)", entry)
+		}
+		overrides[service] = image
+	}
+	return overrides, nil
+}
+
+// serviceSource is the part of a compose project that parseComposeServices
+// reads. *compose.Project implements it.
+type serviceSource interface {
+	GetStartupOrder() ([]string, error)
+	IsServiceExcluded(name string) bool
+	IsInitService(name string) bool
+	GetServiceImage(name string) string
+	GetServiceDependencies(name string) []string
+	GetPublishedPorts(name string) ([]compose.PortMapping, error)
+	GetIngressPort(name string) (int32, error)
+	GetServiceEnvironment(name string) map[string]string
+	GetServiceSecretKeys(name string) []string
+	GetServiceHealthcheck(name string) *compose.Healthcheck
+	GetServiceVolumes(name string) []compose.Volume
+	GetServiceEntrypoint(name string) []string
+	GetServiceCommand(name string) []string
+	GetServiceRestart(name string) string
+}
+
+func parseComposeServices(project serviceSource, imageOverrides map[string]string, resources serviceResources, envFilter compose.EnvFilter) ([]azure.ContainerConfig, []github.ServiceInfo, error) {
+	var containers []azure.ContainerConfig
+	var services []github.ServiceInfo
+
+	order, err := project.GetStartupOrder()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve startup order: %w", err)
+	}
+
+	for _, name := range order {
+		if project.IsServiceExcluded(name) {
+			slog.Info("skipping service excluded by label", "service", name, "label", "draftdeploy.deploy=false")
+			continue
+		}
+
+		image := project.GetServiceImage(name)
+		if override, ok := imageOverrides[name]; ok {
+			slog.Info("applying image override", "service", name, "image", override)
+			image = override
+		}
+		if image == "" {
+			slog.Info("skipping service with build config", "service", name)
+			continue
+		}
+		if err := azure.ValidateImageReference(image); err != nil {
+			return nil, nil, fmt.Errorf("service %s: %w", name, err)
+		}
+
+		if deps := project.GetServiceDependencies(name); len(deps) > 0 {
+			slog.Info("service dependencies", "service", name, "depends_on", deps)
+		}
+
+		published, err := project.GetPublishedPorts(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		env, dropped := envFilter.Apply(project.GetServiceEnvironment(name), project.GetServiceSecretKeys(name))
+		switch {
+		case len(dropped) == 0:
+		case envFilter.Forward:
+			slog.Warn("dropping environment variables that match DD_ENV_DENY or miss DD_ENV_ALLOW", "service", name, "keys", dropped)
+		default:
+			slog.Info("not forwarding environment variables, set DD_FORWARD_ENV=true to pass them", "service", name, "keys", dropped)
+		}
+
+		isInit := project.IsInitService(name)
+		restart := azure.RestartNever
+		if !isInit {
+			restart = restartMode(name, project.GetServiceRestart(name))
+		}
+
+		var ports, udpPorts []int32
+		for _, m := range published {
+			if m.Protocol == compose.ProtocolUDP {
+				udpPorts = append(udpPorts, m.Target)
+				continue
+			}
+			ports = append(ports, m.Target)
+		}
+
+		ingressPort, err := project.GetIngressPort(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ingressPort != 0 {
+			slog.Info("using labeled ingress port", "service", name, "port", ingressPort)
+			i := slices.Index(ports, ingressPort)
+			ports = slices.Concat([]int32{ingressPort}, ports[:i], ports[i+1:])
+		}
+
+		containers = append(containers, azure.ContainerConfig{
+			Name:         name,
+			Image:        image,
+			Ports:        ports,
+			UDPPorts:     udpPorts,
+			Environment:  env,
+			CPU:          resources.cpu,
+			MemoryGB:     resources.memoryGB,
+			Probe:        probeFromHealthcheck(project.GetServiceHealthcheck(name)),
+			VolumeMounts: volumeMounts(name, project.GetServiceVolumes(name)),
+			Command:      containerCommand(name, project.GetServiceEntrypoint(name), project.GetServiceCommand(name)),
+			Restart:      restart,
+			Init:         isInit,
+		})
+
+		services = append(services, github.ServiceInfo{
+			Name:        name,
+			Ports:       ports,
+			UDPPorts:    udpPorts,
+			IngressPort: ingressPort,
+		})
+	}
+
+	return containers, services, nil
+}
+
+// pathRouter fronts the routed services with one router container and
+// records each service's path for the PR comment.
+func pathRouter(containers []azure.ContainerConfig, services []github.ServiceInfo, routes []compose.Route) (azure.ContainerConfig, error) {
+	azureRoutes := make([]azure.Route, 0, len(routes))
+	for _, route := range routes {
+		i := slices.IndexFunc(containers, func(c azure.ContainerConfig) bool { return c.Name == route.Service })
+		switch {
+		case i < 0:
+			return azure.ContainerConfig{}, fmt.Errorf("service %s has a draftdeploy.path label but is not deployed", route.Service)
+		case !containers[i].Restart.LongRunning():
+			return azure.ContainerConfig{}, fmt.Errorf("service %s runs to completion and cannot serve %s", route.Service, route.Path)
+		case len(containers[i].Ports) == 0:
+			return azure.ContainerConfig{}, fmt.Errorf("service %s has no TCP port to route %s to", route.Service, route.Path)
+		}
+
+		slog.Info("routing path to service", "path", route.Path, "service", route.Service, "port", containers[i].Ports[0])
+		azureRoutes = append(azureRoutes, azure.Route{Path: route.Path, Port: containers[i].Ports[0]})
+		services[i].Path = route.Path
+	}
+	if slices.ContainsFunc(containers, func(c azure.ContainerConfig) bool { return c.Name == azure.RouterName }) {
+		return azure.ContainerConfig{}, fmt.Errorf("service name %s is reserved for the path router", azure.RouterName)
+	}
+	return azure.RouterContainer(azureRoutes), nil
+}
+
+func restartMode(service, restart string) azure.RestartMode {
+	switch restart {
+	case compose.RestartNo:
+		slog.Info("service runs to completion (restart: no), it will not be published on the public IP", "service", service)
+		return azure.RestartNever
+	case compose.RestartOnFailure:
+		slog.Info("service runs to completion (restart: on-failure), it will not be published on the public IP", "service", service)
+		return azure.RestartOnFailure
+	default:
+		return azure.RestartAlways
+	}
+}
+
+func warnMixedRestart(containers []azure.ContainerConfig) {
+	longRunning := slices.ContainsFunc(containers, func(c azure.ContainerConfig) bool { return c.Restart.LongRunning() })
+	if !longRunning {
+		return
+	}
+	for _, c := range containers {
+		if !c.Init && !c.Restart.LongRunning() {
+			slog.Warn("one-shot service shares a container group with long-running services and will be restarted each time it exits", "service", c.Name)
+		}
+	}
+}
+
+func containerCommand(service string, entrypoint, command []string) []string {
+	if len(entrypoint) == 0 && len(command) > 0 {
+		slog.Warn("compose command replaces the image entrypoint in Azure, set entrypoint to keep it", "service", service)
+	}
+	return slices.Concat(entrypoint, command)
+}
+
+func volumeMounts(service string, volumes []compose.Volume) []azure.VolumeMount {
+	var mounts []azure.VolumeMount
+	for _, v := range volumes {
+		// Bind mounts are reported by project.Warnings.
+		if v.IsBind() {
+			continue
+		}
+
+		// tmpfs and anonymous volumes hold nothing worth keeping, so they
+		// never get a file share.
+		ephemeral := v.Type == compose.VolumeTypeTmpfs || v.Source == ""
+		name := v.Source
+		if ephemeral {
+			name = service + "-" + v.Target
+		}
+		mounts = append(mounts, azure.VolumeMount{
+			Volume:    name,
+			MountPath: v.Target,
+			ReadOnly:  v.ReadOnly,
+			Ephemeral: ephemeral,
+		})
+	}
+	return mounts
+}
+
+func probeFromHealthcheck(hc *compose.Healthcheck) *azure.ProbeConfig {
+	if hc == nil {
+		return nil
+	}
+
+	probe := &azure.ProbeConfig{
+		PeriodSeconds:      int32(hc.Interval / time.Second),
+		TimeoutSeconds:     int32(hc.Timeout / time.Second),
+		FailureThreshold:   int32(hc.Retries),
+		StartPeriodSeconds: int32(hc.StartPeriod / time.Second),
+	}
+	if port, path, ok := hc.HTTPEndpoint(); ok {
+		probe.HTTPPort = port
+		probe.HTTPPath = path
+	} else {
+		probe.Command = hc.Command()
+	}
+	return probe
+}
+
+func resolveComposeFiles(composeFile string) ([]string, error) {
+	var files []string
+	for _, f := range strings.FieldsFunc(composeFile, func(r rune) bool { return r == ',' || r == ':' }) {
+		if f = strings.TrimSpace(f); f != "" {
+			files = append(files, f)
+		}
+	}
+	if len(files) > 0 {
+		return files, nil
+	}
+
+	files, err := compose.Discover(".")
+	if err != nil {
+		return nil, &deployerr.ComposeError{Err: fmt.Errorf("failed to discover compose file: %w", err)}
+	}
+	slog.Info("discovered compose files", "files", files)
+	return files, nil
+}
+
+// composeProjectName names the compose project after the preview, e.g.
+// acme-app-pr42, unless COMPOSE_PROJECT_NAME is set.
+func composeProjectName(cfg deployConfig) string {
+	if strings.TrimSpace(os.Getenv("COMPOSE_PROJECT_NAME")) != "" {
+		return ""
+	}
+	target := naming.Target{PRNumber: cfg.prNumber, Branch: cfg.branch}
+	return strings.Join([]string{cfg.owner, cfg.repo, target.Ref()}, "-")
+}
+
+func deploy(ctx context.Context, cfg deployConfig) error {
+	start := time.Now()
+
+	var commenter *github.Commenter
+	if cfg.githubAuth != nil && !cfg.dryRun {
+		commenter = github.NewCommenterWithTokenSource(cfg.githubAuth, cfg.owner, cfg.repo)
+		commenter.SetTemplate(cfg.comment)
+	}
+
+	var deploymentSucceeded bool
+	if commenter != nil && cfg.headSHA != "" {
+		setCommitStatus(commenter, cfg.headSHA, github.CommitStatePending, "", "Deploying preview environment")
+		defer func() {
+			if !deploymentSucceeded {
+				setCommitStatus(commenter, cfg.headSHA, github.CommitStateFailure, "", "Preview deployment failed")
+			}
+		}()
+	}
+
+	composeFiles, err := resolveComposeFiles(cfg.composeFile)
+	if err != nil {
+		return err
+	}
+
+	if len(cfg.profiles) > 0 {
+		slog.Info("activating compose profiles", "profiles", cfg.profiles)
+	}
+	project, err := compose.LoadWithOptions(compose.LoadOptions{
+		Profiles:    cfg.profiles,
+		ProjectName: composeProjectName(cfg),
+	}, composeFiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load compose file: %w", err)
+	}
+	if errs := project.Validate(cfg.imageOverrides); len(errs) > 0 {
+		for _, err := range errs {
+			slog.Error("invalid compose project", "error", err)
+		}
+		return &deployerr.ComposeError{Err: fmt.Errorf("compose project has %d problems:\n%w", len(errs), errors.Join(errs...))}
+	}
+	for _, warning := range project.Warnings() {
+		slog.Warn("compose project may behave differently in Azure", "warning", warning)
+	}
+
+	labelLocation, err := project.GetLocation()
+	if err != nil {
+		return err
+	}
+	if labelLocation != "" {
+		cfg.location, err = resolveLocation(labelLocation, cfg.location, "")
+		if err != nil {
+			return fmt.Errorf("invalid draftdeploy.location label: %w", err)
+		}
+		slog.Info("using location from compose label", "location", cfg.location)
+	}
+
+	containers, services, err := parseComposeServices(project, cfg.imageOverrides, cfg.resources, cfg.envFilter)
+	if err != nil {
+		return &deployerr.ComposeError{Err: err}
+	}
+	if len(containers) == 0 {
+		return &deployerr.ComposeError{Err: errors.New("no deployable services found (all have build configs without image overrides)")}
+	}
+	warnMixedRestart(containers)
+
+	ingressService := cfg.ingressService
+	if ingressService == "" {
+		ingressService, err = project.GetIngressService()
+		if err != nil {
+			return fmt.Errorf("failed to resolve ingress service: %w", err)
+		}
+	}
+	routes, err := project.GetRoutes()
+	if err != nil {
+		return err
+	}
+	if len(routes) > 0 {
+		if ingressService != "" {
+			return fmt.Errorf("ingress service %s cannot be combined with draftdeploy.path labels", ingressService)
+		}
+		router, err := pathRouter(containers, services, routes)
+		if err != nil {
+			return err
+		}
+		containers = append(containers, router)
+		services = append(services, github.ServiceInfo{Name: router.Name, Ports: router.Ports})
+		ingressService = router.Name
+	}
+
+	if ingressService != "" {
+		slog.Info("using ingress service", "service", ingressService)
+	}
+	ingress := ingressTarget(ingressService, services)
+	for i := range services {
+		services[i].Public = containers[i].Restart.LongRunning() && (ingressService == "" || services[i].Name == ingressService)
+		if ingress != nil {
+			services[i].Public = services[i].Name == ingress.Service
+			services[i].IngressOnly = services[i].Public
+		}
+	}
+
+	secretKeys := slices.Clone(cfg.secretKeys)
+	for _, c := range containers {
+		secretKeys = append(secretKeys, project.GetServiceSecretKeys(c.Name)...)
+	}
+
+	var registryCredentials []azure.RegistryCredential
+	if cfg.registry != nil {
+		slog.Info("using private registry credentials", "server", cfg.registry.Server)
+		registryCredentials = append(registryCredentials, *cfg.registry)
+	}
+
+	deployCfg := azure.DeployConfig{
+		ResourceGroup:       cfg.resourceGroup,
+		Name:                cfg.containerName,
+		Location:            cfg.location,
+		Containers:          containers,
+		DNSNameLabel:        cfg.dnsLabel,
+		IngressService:      ingressService,
+		Ingress:             ingress,
+		Tags:                previewTags(cfg, start),
+		RegistryCredentials: registryCredentials,
+		SecretKeys:          secretKeys,
+		Storage:             cfg.storage,
+		StartupGraceSeconds: int32(cfg.startupGrace / time.Second),
+	}
+
+	cost := azure.CostEstimate(containers, costRatesFromEnv())
+	slog.Info("estimated cost",
+		"vcpu", cost.VCPU,
+		"memory_gb", cost.MemoryGB,
+		"usd_per_hour", fmt.Sprintf("%.4f", cost.PerHour),
+		"usd_per_day", fmt.Sprintf("%.2f", cost.PerDay))
+
+	if cfg.dryRun {
+		printDeployPlan(deployCfg)
+		deploymentSucceeded = true
+		return nil
+	}
+
+	deployer := cfg.deployer
+	if deployer == nil {
+		deployer, err = newDeployer(cfg.subscriptionID)
+		if err != nil {
+			return err
+		}
+	}
+
+	cfg.events.send(notify.Event{Type: notify.EventDeployStarted})
+
+	holder := lockHolderID()
+	lockTTL := defaultDeployTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		lockTTL = time.Until(deadline)
+	}
+	lockWait := timeoutFromEnv("DD_LOCK_WAIT", defaultLockWait)
+	slog.Info("acquiring deploy lock", "resource_group", cfg.resourceGroup, "holder", holder, "wait", lockWait.String())
+	if err := deployer.AcquireDeployLock(ctx, cfg.resourceGroup, cfg.location, holder, deployCfg.Tags, lockTTL, lockWait); err != nil {
+		return err
+	}
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := deployer.ReleaseDeployLock(releaseCtx, cfg.resourceGroup, holder); err != nil {
+			slog.Warn("failed to release deploy lock", "error", err)
+		}
+	}()
+
+	var githubDeploymentID int64
+	if commenter != nil {
+		githubDeploymentID = startGitHubDeployment(ctx, commenter, cfg)
+	}
+
+	defer func() {
+		if !deploymentSucceeded {
+			if githubDeploymentID != 0 {
+				setGitHubDeploymentStatus(commenter, githubDeploymentID, github.DeploymentStateFailure, "")
+			}
+
+			cleanupFailedDeploy(deployer, cfg.resourceGroup)
+		}
+	}()
+
+	// Earlier versions named the group without the repository hash. Its DNS
+	// label would block the new group, so it is removed first.
+	if cfg.legacyName != cfg.containerName {
+		if err := deployer.Delete(ctx, cfg.resourceGroup, cfg.legacyName); err != nil && !azure.IsNotFound(err) {
+			return fmt.Errorf("failed to delete container group %s: %w", cfg.legacyName, err)
+		}
+	}
+
+	slog.Info("deploying to Azure", "resource_group", cfg.resourceGroup, "location", cfg.location)
+	result, err := deployer.Deploy(ctx, deployCfg)
+	if err != nil {
+		err = fmt.Errorf("failed to deploy: %w", err)
+		// Azure rejected the group before any container started.
+		var quotaErr *deployerr.QuotaError
+		if errors.As(err, &quotaErr) {
+			return err
+		}
+		if logs := collectContainerLogs(deployer, cfg, containers); len(logs) > 0 {
+			return &deployFailure{err: err, logs: logs}
+		}
+		return err
+	}
+	fqdn := result.FQDN
+
+	deployTime := time.Since(start)
+	slog.Info("deployment complete",
+		"fqdn", fqdn,
+		"ip_address", result.IPAddress,
+		"provisioning_state", result.ProvisioningState,
+		"container_group_id", result.ContainerGroupID,
+		"deploy_time", deployTime.Round(time.Second),
+		"resource_group_time", result.ResourceGroupTime.Round(time.Second),
+		"container_group_time", result.ContainerGroupTime.Round(time.Second))
+
+	url := github.PreviewURL(fqdn, services)
+	if cfg.customDomain != "" {
+		url = github.PreviewURL(cfg.customDomain, services)
+	}
+	info := github.DeploymentInfo{
+		FQDN:               fqdn,
+		CustomDomain:       cfg.customDomain,
+		ContainerGroup:     cfg.containerName,
+		ProvisioningState:  result.ProvisioningState,
+		Services:           services,
+		DeployTime:         deployTime,
+		ResourceGroupTime:  result.ResourceGroupTime,
+		ContainerGroupTime: result.ContainerGroupTime,
+		LogsURL:            workflowRunURL(),
+		CostPerDay:         cost.PerDay,
+		Readiness:          github.ReadinessProvisioning,
+		SHA:                cfg.headSHA,
+	}
+
+	// PR comments are edited in place, so reviewers get the link right away
+	// and the status is filled in once the group settles. Commit comments
+	// cannot be edited and are posted once.
+	if commenter != nil && cfg.branch == "" {
+		postDeploymentComment(ctx, commenter, cfg, info)
+	}
+	info.Readiness = waitForReadiness(ctx, deployer, cfg, fqdn, services)
+	// The deferred cleanup marks the deployment and commit as failed, and
+	// the failure comment replaces the one posted above.
+	if info.Readiness == github.ReadinessFailed {
+		err := errors.New("failed to deploy: container group failed to start")
+		if logs := collectContainerLogs(deployer, cfg, containers); len(logs) > 0 {
+			return &deployFailure{err: err, logs: logs}
+		}
+		return err
+	}
+
+	if commenter != nil {
+		postDeploymentComment(ctx, commenter, cfg, info)
+		if githubDeploymentID != 0 {
+			setGitHubDeploymentStatus(commenter, githubDeploymentID, github.DeploymentStateSuccess, url)
+		}
+		if cfg.headSHA != "" {
+			setCommitStatus(commenter, cfg.headSHA, github.CommitStateSuccess, url, "Preview environment is live")
+		}
+	}
+
+	if err := setGitHubOutput("url", url); err != nil {
+		slog.Warn("failed to set url output", "error", err)
+	}
+	if err := setGitHubOutput("resource-group", cfg.resourceGroup); err != nil {
+		slog.Warn("failed to set resource-group output", "error", err)
+	}
+	if err := writeDeploymentOutput(newDeploymentOutput(cfg, result, url, services, deployTime)); err != nil {
+		slog.Warn("failed to write deployment output", "error", err)
+	}
+	if err := writeStepSummary(github.DeploymentSummary(info, cfg.resourceGroup)); err != nil {
+		slog.Warn("failed to write step summary", "error", err)
+	}
+
+	cfg.events.send(notify.Event{
+		Type:            notify.EventDeploySucceeded,
+		FQDN:            fqdn,
+		URL:             url,
+		DurationSeconds: deployTime.Round(time.Second).Seconds(),
+	})
+
+	deploymentSucceeded = true
+	return nil
+}
+
+func newDeploymentOutput(cfg deployConfig, result azure.DeployResult, url string, services []github.ServiceInfo, deployTime time.Duration) deploymentOutput {
+	out := deploymentOutput{
+		FQDN:              result.FQDN,
+		CustomDomain:      cfg.customDomain,
+		URL:               url,
+		ResourceGroup:     cfg.resourceGroup,
+		ContainerGroupID:  result.ContainerGroupID,
+		ProvisioningState: result.ProvisioningState,
+		IPAddress:         result.IPAddress,
+		Environment:       cfg.environment,
+		Services:          make([]serviceOutput, 0, len(services)),
+		DeployTimeSeconds: deployTime.Round(time.Second).Seconds(),
+	}
+	for _, svc := range services {
+		out.Services = append(out.Services, serviceOutput{
+			Name:     svc.Name,
+			Ports:    svc.Ports,
+			UDPPorts: svc.UDPPorts,
+			Public:   svc.Public,
+			Path:     svc.Path,
+		})
+	}
+	return out
+}
+
+func writeDeploymentOutput(out deploymentOutput) error {
+	data, err := json.Marshal(out)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deployment output: %w", err)
+	}
+
+	if path := strings.TrimSpace(os.Getenv("DD_JSON_OUTPUT")); path != "" {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return setGitHubOutput("deployment", string(data))
+}
+
+// waitForReadiness waits for the container group to start and then for the
+// public service to answer, sharing DD_READINESS_TIMEOUT between the two.
+func waitForReadiness(ctx context.Context, deployer *azure.Deployer, cfg deployConfig, fqdn string, services []github.ServiceInfo) string {
+	timeout := timeoutFromEnv("DD_READINESS_TIMEOUT", defaultReadinessTimeout)
+	deadline := time.Now().Add(timeout)
+
+	slog.Info("waiting for container group to start", "container_group", cfg.containerName, "timeout", timeout.String())
+	status, err := deployer.WaitForGroupStatus(ctx, cfg.resourceGroup, cfg.containerName, timeout)
+	if err != nil {
+		slog.Warn("container group still starting", "state", status.State, "error", err)
+		return github.ReadinessProvisioning
+	}
+	if status.Phase() == azure.GroupFailed {
+		slog.Warn("container group failed to start", "provisioning_state", status.ProvisioningState, "state", status.State)
+		return github.ReadinessFailed
+	}
+
+	host, ok := readinessHost(fqdn, services)
+	if !ok {
+		slog.Info("skipping readiness check, no public TCP port")
+		return github.ReadinessReady
+	}
+
+	path := strings.TrimSpace(os.Getenv("DD_READINESS_PATH"))
+	if path == "" {
+		path = defaultReadinessPath
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return github.ReadinessProvisioning
+	}
+
+	slog.Info("waiting for preview to serve", "host", host, "path", path, "timeout", remaining.Round(time.Second).String())
+	if err := deployer.WaitForReady(ctx, host, path, remaining); err != nil {
+		slog.Warn("preview still provisioning", "error", err)
+		return github.ReadinessProvisioning
+	}
+	return github.ReadinessReady
+}
+
+func readinessHost(fqdn string, services []github.ServiceInfo) (string, bool) {
+	port, ok := github.PrimaryPort(services)
+	switch {
+	case !ok:
+		return "", false
+	case port == 80:
+		return fqdn, true
+	default:
+		return fmt.Sprintf("%s:%d", fqdn, port), true
+	}
+}
+
+func lockHolderID() string {
+	if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" {
+		return fmt.Sprintf("run-%s-%s", runID, cmp.Or(os.Getenv("GITHUB_RUN_ATTEMPT"), "1"))
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", cmp.Or(host, "local"), os.Getpid())
+}
+
+// ingressTarget publishes only the draftdeploy.port label of the ingress
+// service, if it has one, instead of every port it exposes.
+func ingressTarget(ingressService string, services []github.ServiceInfo) *azure.IngressTarget {
+	i := slices.IndexFunc(services, func(svc github.ServiceInfo) bool { return svc.Name == ingressService })
+	if ingressService == "" || i < 0 || services[i].IngressPort == 0 {
+		return nil
+	}
+	slog.Info("publishing only the labeled ingress port", "service", ingressService, "port", services[i].IngressPort)
+	return &azure.IngressTarget{
+		Service:   ingressService,
+		Port:      services[i].IngressPort,
+		External:  true,
+		Transport: "tcp",
+	}
+}
+
+func previewTags(cfg deployConfig, created time.Time) map[string]string {
+	tags := azure.MergeTags(cfg.extraTags, azure.LabelTags(cfg.labels), azure.ManagedTags(cfg.owner, cfg.repo, cfg.prNumber, created, cfg.ttl))
+	if cfg.branch != "" {
+		tags[azure.TagBranch] = cfg.branch
+	}
+	return tags
+}
+
+func postDeploymentComment(ctx context.Context, commenter *github.Commenter, cfg deployConfig, info github.DeploymentInfo) {
+	if cfg.branch == "" {
+		if err := commenter.PostDeployment(ctx, cfg.prNumber, info); err != nil {
+			slog.Warn("failed to post comment", "error", err)
+		}
+		return
+	}
+
+	target := strings.TrimSpace(os.Getenv("DD_BRANCH_COMMENT"))
+	if target == "none" || cfg.headSHA == "" {
+		return
+	}
+	if target != "" && target != "commit" {
+		slog.Warn("unknown DD_BRANCH_COMMENT value, posting a commit comment", "value", target)
+	}
+	if err := commenter.PostCommitDeployment(ctx, cfg.headSHA, info); err != nil {
+		slog.Warn("failed to post commit comment", "error", err)
+	}
+}
+
+func startGitHubDeployment(ctx context.Context, commenter *github.Commenter, cfg deployConfig) int64 {
+	if cfg.headSHA == "" {
+		slog.Warn("skipping GitHub deployment, head SHA unknown")
+		return 0
+	}
+
+	environment := cfg.environment
+	id, err := commenter.CreateDeployment(ctx, cfg.headSHA, environment)
+	if err != nil {
+		slog.Warn("failed to create GitHub deployment", "error", err)
+		return 0
+	}
+
+	slog.Info("created GitHub deployment", "deployment_id", id, "environment", environment)
+	setGitHubDeploymentStatus(commenter, id, github.DeploymentStateInProgress, "")
+	return id
+}
+
+func setGitHubDeploymentStatus(commenter *github.Commenter, id int64, state, envURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := commenter.SetDeploymentStatus(ctx, id, state, envURL); err != nil {
+		slog.Warn("failed to set GitHub deployment status", "state", state, "error", err)
+	}
+}
+
+func workflowRunURL() string {
+	server := strings.TrimSpace(os.Getenv("GITHUB_SERVER_URL"))
+	repository := strings.TrimSpace(os.Getenv("GITHUB_REPOSITORY"))
+	runID := strings.TrimSpace(os.Getenv("GITHUB_RUN_ID"))
+	if server == "" || repository == "" || runID == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/actions/runs/%s", server, repository, runID)
+}
+
+// collectContainerLogs fetches the tail of each container's log so a failed
+// deploy can be diagnosed after its resource group has been deleted.
+func collectContainerLogs(deployer *azure.Deployer, cfg deployConfig, containers []azure.ContainerConfig) []github.ContainerLog {
+	if cfg.logLines == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var logs []github.ContainerLog
+	for _, c := range containers {
+		output, err := deployer.GetContainerLogs(ctx, cfg.resourceGroup, cfg.containerName, c.Name, cfg.logLines)
+		if err != nil {
+			slog.Warn("failed to fetch container logs", "container", c.Name, "error", err)
+			continue
+		}
+		if strings.TrimSpace(output) == "" {
+			continue
+		}
+		fmt.Printf("::group::Logs for %s\n%s\n::endgroup::\n", c.Name, strings.TrimRight(output, "\n"))
+		logs = append(logs, github.ContainerLog{Container: c.Name, Output: output})
+	}
+	return logs
+}
+
+// cleanupFailedDeploy deletes the resource group of a failed deploy. It
+// gets its own context, since the deploy's may have been cancelled.
+func cleanupFailedDeploy(deployer *azure.Deployer, resourceGroup string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	slog.Warn("deployment failed, attempting cleanup", "resource_group", resourceGroup)
+	if err := deployer.DeleteResourceGroup(ctx, resourceGroup); err != nil {
+		slog.Error("failed to cleanup resource group", "error", err)
+	}
+}
+
+func reportDeployFailure(cfg deployConfig, deployErr error, elapsed time.Duration) {
+	cfg.events.send(notify.Event{
+		Type:            notify.EventDeployFailed,
+		DurationSeconds: elapsed.Round(time.Second).Seconds(),
+		Error:           deployErr.Error(),
+	})
+
+	if cfg.githubAuth == nil || cfg.dryRun || cfg.prNumber == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var logs []github.ContainerLog
+	var failure *deployFailure
+	if errors.As(deployErr, &failure) {
+		logs = failure.logs
+	}
+
+	commenter := github.NewCommenterWithTokenSource(cfg.githubAuth, cfg.owner, cfg.repo)
+	if err := commenter.PostFailure(ctx, cfg.prNumber, deployErr.Error(), failureHint(deployErr), workflowRunURL(), logs); err != nil {
+		slog.Warn("failed to post failure comment", "error", err)
+	}
+}
+
+// exitCode maps the error run returned to one of the exit codes.
+func exitCode(err error) int {
+	var (
+		quotaErr   *deployerr.QuotaError
+		authErr    *deployerr.AuthError
+		timeoutErr *deployerr.TimeoutError
+		composeErr *deployerr.ComposeError
+		configErr  *deployerr.ConfigError
+	)
+	switch {
+	case errors.As(err, &quotaErr):
+		return exitQuota
+	case errors.As(err, &authErr):
+		return exitAuth
+	case errors.As(err, &timeoutErr):
+		return exitTimeout
+	case errors.As(err, &composeErr), errors.As(err, &configErr):
+		return exitConfig
+	default:
+		return exitFailure
+	}
+}
+
+// failureHint suggests a next step for the failure comment, or returns ""
+// when the error is not one of the deployerr types.
+func failureHint(err error) string {
+	var (
+		quotaErr   *deployerr.QuotaError
+		authErr    *deployerr.AuthError
+		timeoutErr *deployerr.TimeoutError
+		composeErr *deployerr.ComposeError
+	)
+	switch {
+	case errors.As(err, &quotaErr):
+		return fmt.Sprintf("Close or tear down unused previews, or request a higher %s quota in %s.", quotaErr.Resource, quotaErr.Location)
+	case errors.As(err, &authErr):
+		if authErr.Provider == "GitHub" {
+			return "Check the GitHub token or app installation used by the workflow."
+		}
+		return "Check the Azure login step and that the identity may manage resource groups in the subscription."
+	case errors.As(err, &timeoutErr):
+		return "Re-run the workflow, or raise DD_DEPLOY_TIMEOUT or DD_LOCK_WAIT if previews regularly take this long."
+	case errors.As(err, &composeErr):
+		return "Fix the compose file and push again."
+	default:
+		return ""
+	}
+}
+
+func notifiersFromEnv(dryRun bool) ([]*notify.Notifier, error) {
+	if dryRun {
+		return nil, nil
+	}
+
+	var notifiers []*notify.Notifier
+	if webhookURL := strings.TrimSpace(os.Getenv("DD_WEBHOOK_URL")); webhookURL != "" {
+		if err := validateWebhookURL("DD_WEBHOOK_URL", webhookURL); err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, notify.NewNotifier(webhookURL, os.Getenv("DD_WEBHOOK_SECRET")))
+	}
+	if slackURL := strings.TrimSpace(os.Getenv("DD_SLACK_WEBHOOK_URL")); slackURL != "" {
+		if err := validateWebhookURL("DD_SLACK_WEBHOOK_URL", slackURL); err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, notify.NewSlackNotifier(slackURL))
+	}
+	return notifiers, nil
+}
+
+func validateWebhookURL(name, value string) error {
+	u, err := neturl.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid %s: must be an http(s) URL", name)
+	}
+	return nil
+}
+
+// eventSender fills in the preview's identity on every event and delivers
+// it to each configured notifier. A nil sender sends nothing.
+type eventSender struct {
+	notifiers []*notify.Notifier
+	base      notify.Event
+}
+
+func newEventSender(notifiers []*notify.Notifier, base notify.Event) *eventSender {
+	if len(notifiers) == 0 {
+		return nil
+	}
+	return &eventSender{notifiers: notifiers, base: base}
+}
+
+func (s *eventSender) send(event notify.Event) {
+	if s == nil {
+		return
+	}
+	event.Repository = s.base.Repository
+	event.PR = s.base.PR
+	event.Title = s.base.Title
+	event.Branch = s.base.Branch
+	event.ResourceGroup = s.base.ResourceGroup
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, n := range s.notifiers {
+		if err := n.Notify(ctx, event); err != nil {
+			slog.Warn("failed to send notification", "type", event.Type, "error", err)
+		}
+	}
+}
+
+func setCommitStatus(commenter *github.Commenter, sha, state, targetURL, description string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := commenter.SetCommitStatus(ctx, sha, state, targetURL, description); err != nil {
+		slog.Warn("failed to set commit status", "state", state, "error", err)
+	}
+}
+
+func printDeployPlan(cfg azure.DeployConfig) {
+	slog.Info("planned deployment",
+		"resource_group", cfg.ResourceGroup,
+		"container_group", cfg.Name,
+		"location", cfg.Location,
+		"dns_label", cfg.DNSNameLabel,
+		"ingress_service", cfg.IngressService)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Dry run: planned Azure resources\n")
+	fmt.Fprintf(&sb, "  Resource group:  %s (%s)\n", cfg.ResourceGroup, cfg.Location)
+	fmt.Fprintf(&sb, "  Container group: %s\n", cfg.Name)
+	fmt.Fprintf(&sb, "  DNS label:       %s\n", cfg.DNSNameLabel)
+	fmt.Fprintf(&sb, "  Containers:\n")
+
+	for _, c := range cfg.Containers {
+		if c.Init {
+			slog.Info("planned init container", "name", c.Name, "image", c.Image, "command", c.Command)
+			fmt.Fprintf(&sb, "    - %s: image=%s init=true\n", c.Name, c.Image)
+			continue
+		}
+		public := c.Restart.LongRunning() && (cfg.IngressService == "" || c.Name == cfg.IngressService)
+		if cfg.Ingress != nil {
+			public = c.Name == cfg.Ingress.Service
+		}
+		slog.Info("planned container",
+			"name", c.Name,
+			"image", c.Image,
+			"ports", c.Ports,
+			"public", public,
+			"cpu", c.CPU,
+			"memory_gb", c.MemoryGB)
+		fmt.Fprintf(&sb, "    - %s: image=%s ports=%v public=%t cpu=%.2f memory=%.2fGB\n",
+			c.Name, c.Image, c.Ports, public, c.CPU, c.MemoryGB)
+	}
+
+	fmt.Print(sb.String())
+}
+
+func teardown(ctx context.Context, cfg teardownConfig) error {
+	if cfg.merged && cfg.mergedGrace > 0 {
+		return keepMergedPreview(ctx, cfg)
+	}
+
+	if cfg.dryRun {
+		slog.Info("planned teardown",
+			"resource_group", cfg.resourceGroup,
+			"container_group", cfg.containerName)
+		fmt.Printf("Dry run: would delete resource group %s\n", cfg.resourceGroup)
+		return nil
+	}
+
+	deployer, err := newDeployer(cfg.subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	slog.Info("tearing down preview", "resource_group", cfg.resourceGroup, "container_group", cfg.containerName)
+	existed, err := deployer.Teardown(ctx, cfg.resourceGroup, cfg.containerName)
+	if err != nil {
+		return fmt.Errorf("failed to tear down preview: %w", err)
+	}
+	if !existed {
+		slog.Info("resource group already absent, nothing to delete", "resource_group", cfg.resourceGroup)
+	}
+	if cfg.storage != nil {
+		deleted, err := deployer.DeleteFileShares(ctx, cfg.storage, cfg.resourceGroup)
+		if err != nil {
+			return fmt.Errorf("failed to delete preview volumes: %w", err)
+		}
+		slog.Info("deleted file shares", "count", deleted)
+	}
+
+	slog.Info("teardown complete")
+	cfg.events.send(notify.Event{Type: notify.EventTornDown})
+	if err := writeStepSummary(github.TeardownSummary(cfg.resourceGroup, existed, time.Since(start))); err != nil {
+		slog.Warn("failed to write step summary", "error", err)
+	}
+
+	if cfg.githubAuth != nil {
+		commenter := github.NewCommenterWithTokenSource(cfg.githubAuth, cfg.owner, cfg.repo)
+		commenter.SetTemplate(cfg.comment)
+		if cfg.prNumber != 0 {
+			if err := commenter.PostTeardown(ctx, cfg.prNumber, github.DeploymentInfo{
+				LogsURL: workflowRunURL(),
+			}); err != nil {
+				slog.Warn("failed to post teardown comment", "error", err)
+			}
+		}
+		if err := commenter.DeactivateDeployments(ctx, cfg.environment); err != nil {
+			slog.Warn("failed to deactivate GitHub deployments", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// keepMergedPreview leaves a merged PR's preview running for the grace
+// period so it can be checked against the merge, and lets the reaper delete
+// it afterwards.
+func keepMergedPreview(ctx context.Context, cfg teardownConfig) error {
+	expires := time.Now().Add(cfg.mergedGrace)
+	if cfg.dryRun {
+		slog.Info("planned merge grace period",
+			"resource_group", cfg.resourceGroup,
+			"expires", expires.UTC().Format(time.RFC3339))
+		fmt.Printf("Dry run: would keep resource group %s until %s\n", cfg.resourceGroup, expires.UTC().Format(time.RFC3339))
+		return nil
+	}
+
+	deployer, err := newDeployer(cfg.subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("pull request merged, keeping preview", "resource_group", cfg.resourceGroup, "grace", cfg.mergedGrace.String())
+	existed, err := deployer.ExpireAt(ctx, cfg.resourceGroup, expires)
+	if err != nil {
+		return err
+	}
+	if !existed {
+		slog.Info("resource group already absent, nothing to keep", "resource_group", cfg.resourceGroup)
+		return nil
+	}
+
+	if cfg.githubAuth == nil || cfg.prNumber == 0 {
+		return nil
+	}
+
+	fqdn, err := deployer.FindFQDN(ctx, cfg.resourceGroup)
+	if err != nil {
+		slog.Warn("failed to look up preview FQDN", "error", err)
+	}
+	commenter := github.NewCommenterWithTokenSource(cfg.githubAuth, cfg.owner, cfg.repo)
+	if err := commenter.PostMerged(ctx, cfg.prNumber, github.DeploymentInfo{
+		FQDN:    fqdn,
+		LogsURL: workflowRunURL(),
+	}, expires); err != nil {
+		slog.Warn("failed to post merge comment", "error", err)
+	}
+	return nil
+}
+
+func reap(ctx context.Context) error {
+	subscriptionID := strings.TrimSpace(os.Getenv("AZURE_SUBSCRIPTION_ID"))
+	if subscriptionID == "" {
+		return fmt.Errorf("AZURE_SUBSCRIPTION_ID not set")
+	}
+
+	prefix := strings.TrimSpace(os.Getenv("DD_REAP_PREFIX"))
+	if prefix == "" {
+		prefix = strings.TrimSpace(os.Getenv("DD_RG_PREFIX"))
+	}
+	if prefix == "" {
+		prefix = naming.DefaultResourceGroupPrefix
+	}
+
+	storage, err := storageFromEnv()
+	if err != nil {
+		return &deployerr.ConfigError{Err: err}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reapTimeout)
+	defer cancel()
+
+	deployer, err := newDeployer(subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	expired, err := deployer.ListExpiredResourceGroups(ctx, prefix, time.Now())
+	if err != nil {
+		return err
+	}
+
+	slog.Info("found expired preview environments", "count", len(expired), "prefix", prefix)
+
+	concurrency := azure.DefaultDeleteConcurrency
+	if value := strings.TrimSpace(os.Getenv("DD_REAP_CONCURRENCY")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid DD_REAP_CONCURRENCY value %q: must be a positive integer", value)
+		}
+		concurrency = n
+	}
+
+	slog.Info("reaping resource groups", "resource_groups", expired, "concurrency", concurrency)
+	results, err := deployer.DeleteResourceGroups(ctx, expired, concurrency)
+
+	var errs, shareErrs []error
+	deleted := 0
+	for _, name := range expired {
+		deleteErr, attempted := results[name]
+		switch {
+		case !attempted:
+			slog.Warn("skipped resource group, reap was cancelled", "resource_group", name)
+		case deleteErr != nil:
+			slog.Error("failed to reap resource group", "resource_group", name, "error", deleteErr)
+			errs = append(errs, fmt.Errorf("%s: %w", name, deleteErr))
+		default:
+			slog.Info("reaped resource group", "resource_group", name)
+			deleted++
+			if storage == nil {
+				continue
+			}
+			if _, err := deployer.DeleteFileShares(ctx, storage, name); err != nil {
+				slog.Error("failed to delete file shares of reaped preview", "resource_group", name, "error", err)
+				shareErrs = append(shareErrs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to reap %d of %d resource groups: %w", len(expired)-deleted, len(expired), errors.Join(errs...))
+	}
+	if len(shareErrs) > 0 {
+		return fmt.Errorf("failed to delete file shares of %d reaped previews: %w", len(shareErrs), errors.Join(shareErrs...))
+	}
+
+	slog.Info("reap complete", "deleted", deleted)
+	return nil
+}
+
+func list(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	repository := flags.String("repo", os.Getenv("GITHUB_REPOSITORY"), "repository to list previews for (owner/repo)")
+	asJSON := flags.Bool("json", false, "print deployments as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	subscriptionID := strings.TrimSpace(os.Getenv("AZURE_SUBSCRIPTION_ID"))
+	if subscriptionID == "" {
+		return fmt.Errorf("AZURE_SUBSCRIPTION_ID not set")
+	}
+	owner, repo, ok := strings.Cut(strings.TrimSpace(*repository), "/")
+	if !ok || owner == "" || repo == "" {
+		return fmt.Errorf("invalid repository %q (expected owner/repo)", *repository)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, listTimeout)
+	defer cancel()
+
+	deployer, err := newDeployer(subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	summaries, err := deployer.ListDeployments(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deployments := make([]listedDeployment, 0, len(summaries))
+	for _, s := range summaries {
+		d := listedDeployment{
+			ResourceGroup: s.ResourceGroup,
+			PR:            s.PR,
+			FQDN:          s.FQDN,
+			Created:       s.Created,
+		}
+		if !s.Created.IsZero() {
+			d.AgeSeconds = now.Sub(s.Created).Round(time.Second).Seconds()
+		}
+		deployments = append(deployments, d)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(deployments)
+	}
+	return printDeployments(os.Stdout, deployments)
+}
+
+func printDeployments(w io.Writer, deployments []listedDeployment) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PR\tRESOURCE GROUP\tFQDN\tCREATED\tAGE")
+	for _, d := range deployments {
+		created, age := "-", "-"
+		if !d.Created.IsZero() {
+			created = d.Created.UTC().Format(time.RFC3339)
+			age = (time.Duration(d.AgeSeconds) * time.Second).String()
+		}
+		fqdn := d.FQDN
+		if fqdn == "" {
+			fqdn = "-"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", d.PR, d.ResourceGroup, fqdn, created, age)
+	}
+	return tw.Flush()
 }
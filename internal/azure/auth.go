@@ -1,16 +1,79 @@
 package azure
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/LoriKarikari/draftdeploy/internal/deployerr"
 )
 
+const authProvider = "Azure"
+
 func NewCredential() (azcore.TokenCredential, error) {
+	credential, err := newDefaultCredential()
+	if err != nil {
+		return nil, err
+	}
+	return &refreshableCredential{credential: credential, newCredential: newDefaultCredential}, nil
+}
+
+func newDefaultCredential() (azcore.TokenCredential, error) {
 	credential, err := azidentity.NewDefaultAzureCredential(nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create credential: %w", err)
+		return nil, &deployerr.AuthError{Provider: authProvider, Err: err}
 	}
 	return credential, nil
 }
+
+// refreshableCredential lets the deployer swap in a new credential when
+// Azure rejects a token as expired. DefaultAzureCredential caches tokens
+// and would otherwise keep handing out the rejected one.
+type refreshableCredential struct {
+	mu            sync.Mutex
+	credential    azcore.TokenCredential
+	newCredential func() (azcore.TokenCredential, error)
+}
+
+func (c *refreshableCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.mu.Lock()
+	credential := c.credential
+	c.mu.Unlock()
+	return credential.GetToken(ctx, options)
+}
+
+func (c *refreshableCredential) refresh() error {
+	credential, err := c.newCredential()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.credential = credential
+	c.mu.Unlock()
+	return nil
+}
+
+// authError returns a *deployerr.AuthError for err if no token could be
+// acquired or Azure rejected the caller, and nil otherwise.
+func authError(err error) *deployerr.AuthError {
+	var authFailed *azidentity.AuthenticationFailedError
+	if errors.As(err, &authFailed) {
+		return &deployerr.AuthError{Provider: authProvider, Err: err}
+	}
+	// azidentity does not export the error it returns when no credential
+	// in the chain is configured.
+	if strings.Contains(err.Error(), "failed to acquire a token") {
+		return &deployerr.AuthError{Provider: authProvider, Err: err}
+	}
+
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && (respErr.StatusCode == http.StatusUnauthorized || respErr.StatusCode == http.StatusForbidden) {
+		return &deployerr.AuthError{Provider: authProvider, Err: err}
+	}
+	return nil
+}
@@ -1,7 +1,15 @@
 package azure
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 )
 
 func TestNewCredential(t *testing.T) {
@@ -10,3 +18,78 @@ func TestNewCredential(t *testing.T) {
 		t.Logf("expected error in test environment: %v", err)
 	}
 }
+
+func TestAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "forbidden", err: fmt.Errorf("failed to create container group: %w", newResponseError(http.StatusForbidden, "AuthorizationFailed", "no access")), want: true},
+		{name: "unauthorized", err: newResponseError(http.StatusUnauthorized, "InvalidAuthenticationToken", "expired"), want: true},
+		{name: "no credential", err: errors.New("DefaultAzureCredential: failed to acquire a token.\nAttempted credentials:"), want: true},
+		{name: "bad request", err: newResponseError(http.StatusBadRequest, "InvalidParameter", "bad image")},
+		{name: "plain error", err: errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authErr := authError(tt.err)
+			if (authErr != nil) != tt.want {
+				t.Fatalf("authError() = %v, want auth error %t", authErr, tt.want)
+			}
+			if authErr != nil && (authErr.Provider != "Azure" || !errors.Is(authErr, tt.err)) {
+				t.Errorf("unexpected auth error %+v", authErr)
+			}
+		})
+	}
+}
+
+type countingCredential struct {
+	tokens int
+}
+
+func (c *countingCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.tokens++
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestRefreshableCredential(t *testing.T) {
+	first, second := &countingCredential{}, &countingCredential{}
+	credential := &refreshableCredential{
+		credential:    first,
+		newCredential: func() (azcore.TokenCredential, error) { return second, nil },
+	}
+
+	if _, err := credential.GetToken(context.Background(), policy.TokenRequestOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := credential.refresh(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := credential.GetToken(context.Background(), policy.TokenRequestOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first.tokens != 1 || second.tokens != 1 {
+		t.Errorf("expected one token from each credential, got %d and %d", first.tokens, second.tokens)
+	}
+}
+
+func TestRefreshableCredential_RefreshFails(t *testing.T) {
+	original := &countingCredential{}
+	credential := &refreshableCredential{
+		credential:    original,
+		newCredential: func() (azcore.TokenCredential, error) { return nil, errors.New("no credential") },
+	}
+
+	if err := credential.refresh(); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := credential.GetToken(context.Background(), policy.TokenRequestOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if original.tokens != 1 {
+		t.Errorf("expected the original credential to be kept, got %d tokens", original.tokens)
+	}
+}
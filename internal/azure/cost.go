@@ -0,0 +1,42 @@
+package azure
+
+import "time"
+
+// Pay-as-you-go Linux rates for Azure Container Instances in USD. They vary
+// by region and change over time, so estimates are only directional.
+const (
+	DefaultVCPUSecondRate = 0.0000135
+	DefaultGBSecondRate   = 0.0000015
+)
+
+type CostRates struct {
+	VCPUSecond float64
+	GBSecond   float64
+}
+
+type Estimate struct {
+	VCPU     float64
+	MemoryGB float64
+	PerHour  float64
+	PerDay   float64
+}
+
+func DefaultCostRates() CostRates {
+	return CostRates{VCPUSecond: DefaultVCPUSecondRate, GBSecond: DefaultGBSecondRate}
+}
+
+func CostEstimate(containers []ContainerConfig, rates CostRates) Estimate {
+	var est Estimate
+	for _, c := range containers {
+		if c.Init {
+			continue
+		}
+		est.VCPU += c.CPU
+		est.MemoryGB += c.MemoryGB
+	}
+
+	perSecond := est.VCPU*rates.VCPUSecond + est.MemoryGB*rates.GBSecond
+	est.PerHour = perSecond * time.Hour.Seconds()
+	est.PerDay = est.PerHour * 24
+	return est
+}
@@ -0,0 +1,33 @@
+package azure
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCostEstimate(t *testing.T) {
+	containers := []ContainerConfig{
+		{Name: "web", CPU: 1, MemoryGB: 1.5},
+		{Name: "api", CPU: 0.5, MemoryGB: 0.5},
+	}
+
+	est := CostEstimate(containers, CostRates{VCPUSecond: 0.00001, GBSecond: 0.000001})
+
+	if est.VCPU != 1.5 || est.MemoryGB != 2 {
+		t.Errorf("expected 1.5 vCPU and 2 GB, got %.2f vCPU and %.2f GB", est.VCPU, est.MemoryGB)
+	}
+
+	wantPerHour := (1.5*0.00001 + 2*0.000001) * 3600
+	if math.Abs(est.PerHour-wantPerHour) > 1e-9 {
+		t.Errorf("PerHour = %f, want %f", est.PerHour, wantPerHour)
+	}
+	if math.Abs(est.PerDay-wantPerHour*24) > 1e-9 {
+		t.Errorf("PerDay = %f, want %f", est.PerDay, wantPerHour*24)
+	}
+}
+
+func TestCostEstimate_NoContainers(t *testing.T) {
+	if est := CostEstimate(nil, DefaultCostRates()); est.PerDay != 0 {
+		t.Errorf("expected zero cost without containers, got %f", est.PerDay)
+	}
+}
@@ -2,69 +2,214 @@ package azure
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/LoriKarikari/draftdeploy/internal/deployerr"
 	"github.com/cenkalti/backoff/v4"
 )
 
 const (
 	DefaultCPU      = 0.5
 	DefaultMemoryGB = 0.5
-	maxRetryTime    = 2 * time.Minute
 )
 
 type Deployer struct {
-	containerClient *armcontainerinstance.ContainerGroupsClient
-	rgClient        *armresources.ResourceGroupsClient
-	subscriptionID  string
+	// mu guards the clients, which reauthenticate replaces while other
+	// goroutines may be using them.
+	mu               sync.RWMutex
+	containerClient  *armcontainerinstance.ContainerGroupsClient
+	containersClient *armcontainerinstance.ContainersClient
+	rgClient         *armresources.ResourceGroupsClient
+	subscriptionID   string
+	retryPolicy      RetryPolicy
+	credential       azcore.TokenCredential
+	clientOptions    *arm.ClientOptions
 }
 
 type DeployConfig struct {
-	ResourceGroup string
-	Name          string
-	Location      string
-	Containers    []ContainerConfig
-	DNSNameLabel  string
+	ResourceGroup  string
+	Name           string
+	Location       string
+	Containers     []ContainerConfig
+	DNSNameLabel   string
+	IngressService string
+	// Ingress, if set, publishes exactly this port on the group's IP and
+	// overrides the per-service heuristic driven by IngressService.
+	Ingress             *IngressTarget
+	Tags                map[string]string
+	RegistryCredentials []RegistryCredential
+	SecretKeys          []string
+	Storage             *AzureFileStorage
+	StartupGraceSeconds int32
+}
+
+// IngressTarget names the one port a preview is reached on. Transport is
+// "tcp" (the default) or "udp". Container Instances previews are reached
+// through a public IP, so External must be true.
+type IngressTarget struct {
+	Service   string
+	Port      int32
+	External  bool
+	Transport string
+}
+
+type RegistryCredential struct {
+	Server   string
+	Username string
+	Password string
 }
 
 type ContainerConfig struct {
-	Name        string
-	Image       string
-	Ports       []int32
-	Environment map[string]string
-	CPU         float64
-	MemoryGB    float64
+	Name         string
+	Image        string
+	Ports        []int32
+	UDPPorts     []int32
+	Environment  map[string]string
+	CPU          float64
+	MemoryGB     float64
+	Probe        *ProbeConfig
+	VolumeMounts []VolumeMount
+	Command      []string
+	Restart      RestartMode
+	// Init containers run to completion in order before the others start.
+	// They publish no ports and share the group's resources.
+	Init bool
+}
+
+// RestartMode says whether a container is long-running or runs to
+// completion. Container Instances applies one restart policy to the whole
+// group, so the group uses the most persistent mode among its containers.
+// Containers that run to completion are never published on the public IP.
+type RestartMode string
+
+const (
+	RestartAlways    RestartMode = "Always"
+	RestartOnFailure RestartMode = "OnFailure"
+	RestartNever     RestartMode = "Never"
+)
+
+func (m RestartMode) LongRunning() bool {
+	return m == "" || m == RestartAlways
+}
+
+func groupRestartPolicy(containers []ContainerConfig) armcontainerinstance.ContainerGroupRestartPolicy {
+	policy := armcontainerinstance.ContainerGroupRestartPolicyNever
+	for _, c := range containers {
+		switch {
+		case c.Init:
+		case c.Restart.LongRunning():
+			return armcontainerinstance.ContainerGroupRestartPolicyAlways
+		case c.Restart == RestartOnFailure:
+			policy = armcontainerinstance.ContainerGroupRestartPolicyOnFailure
+		}
+	}
+	return policy
 }
 
-func NewDeployer(credential azcore.TokenCredential, subscriptionID string) (*Deployer, error) {
-	containerClient, err := armcontainerinstance.NewContainerGroupsClient(subscriptionID, credential, nil)
+func NewDeployer(credential azcore.TokenCredential, subscriptionID string, retryPolicy RetryPolicy) (*Deployer, error) {
+	return NewDeployerWithOptions(credential, subscriptionID, retryPolicy, nil)
+}
+
+// NewDeployerWithOptions passes options to every Azure SDK client the
+// deployer creates, so callers can set a custom transport or SDK retry policy.
+func NewDeployerWithOptions(credential azcore.TokenCredential, subscriptionID string, retryPolicy RetryPolicy, options *arm.ClientOptions) (*Deployer, error) {
+	d := &Deployer{
+		subscriptionID: subscriptionID,
+		retryPolicy:    retryPolicy.withDefaults(),
+		credential:     credential,
+		clientOptions:  options,
+	}
+	if err := d.newClients(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Deployer) newClients() error {
+	containerClient, err := armcontainerinstance.NewContainerGroupsClient(d.subscriptionID, d.credential, d.clientOptions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create container groups client: %w", err)
+		return fmt.Errorf("failed to create container groups client: %w", err)
 	}
 
-	rgClient, err := armresources.NewResourceGroupsClient(subscriptionID, credential, nil)
+	containersClient, err := armcontainerinstance.NewContainersClient(d.subscriptionID, d.credential, d.clientOptions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create resource groups client: %w", err)
+		return fmt.Errorf("failed to create containers client: %w", err)
 	}
 
-	return &Deployer{
-		containerClient: containerClient,
-		rgClient:        rgClient,
-		subscriptionID:  subscriptionID,
-	}, nil
+	rgClient, err := armresources.NewResourceGroupsClient(d.subscriptionID, d.credential, d.clientOptions)
+	if err != nil {
+		return fmt.Errorf("failed to create resource groups client: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.containerClient = containerClient
+	d.containersClient = containersClient
+	d.rgClient = rgClient
+	return nil
+}
+
+func (d *Deployer) containerGroups() *armcontainerinstance.ContainerGroupsClient {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.containerClient
 }
 
-func (d *Deployer) ensureResourceGroup(ctx context.Context, name, location string) error {
+func (d *Deployer) containers() *armcontainerinstance.ContainersClient {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.containersClient
+}
+
+func (d *Deployer) resourceGroups() *armresources.ResourceGroupsClient {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.rgClient
+}
+
+// reauthenticate refreshes the credential if it supports it and recreates
+// the clients, whose pipelines cache the token Azure just rejected.
+func (d *Deployer) reauthenticate() error {
+	if credential, ok := d.credential.(*refreshableCredential); ok {
+		if err := credential.refresh(); err != nil {
+			return err
+		}
+	}
+	return d.newClients()
+}
+
+func (d *Deployer) ensureResourceGroup(ctx context.Context, name, location string, tags map[string]string) error {
 	operation := func() error {
-		_, err := d.rgClient.CreateOrUpdate(ctx, name, armresources.ResourceGroup{
+		rgTags := buildTags(tags)
+		if existing, err := d.resourceGroups().Get(ctx, name, nil); err == nil {
+			if held := lockTags(existing.Tags); len(held) > 0 {
+				if rgTags == nil {
+					rgTags = make(map[string]*string, len(held))
+				}
+				maps.Copy(rgTags, held)
+			}
+			// A redeploy keeps the original creation time, which list
+			// reports and the TTL is counted from.
+			if created, ok := createdTag(existing.Tags); ok && rgTags[TagCreated] != nil {
+				rgTags[TagCreated] = to.Ptr(created)
+			}
+		}
+
+		_, err := d.resourceGroups().CreateOrUpdate(ctx, name, armresources.ResourceGroup{
 			Location: to.Ptr(location),
+			Tags:     rgTags,
 		}, nil)
 		if err != nil {
 			if isPermanentError(err) {
@@ -75,23 +220,43 @@ func (d *Deployer) ensureResourceGroup(ctx context.Context, name, location strin
 		return nil
 	}
 
-	if err := retryWithBackoff(ctx, operation); err != nil {
+	if err := d.retry(ctx, operation); err != nil {
 		return fmt.Errorf("failed to create resource group: %w", err)
 	}
 	return nil
 }
 
-func (d *Deployer) Deploy(ctx context.Context, config DeployConfig) (string, error) {
-	if err := d.ensureResourceGroup(ctx, config.ResourceGroup, config.Location); err != nil {
-		return "", err
+type DeployResult struct {
+	FQDN              string
+	IPAddress         string
+	ProvisioningState string
+	ContainerGroupID  string
+	// ResourceGroupTime and ContainerGroupTime split the deploy into
+	// ensuring the resource group and creating the container group.
+	ResourceGroupTime  time.Duration
+	ContainerGroupTime time.Duration
+}
+
+func (d *Deployer) Deploy(ctx context.Context, config DeployConfig) (DeployResult, error) {
+	start := time.Now()
+	if err := d.ensureResourceGroup(ctx, config.ResourceGroup, config.Location, config.Tags); err != nil {
+		return DeployResult{}, deployError(err, config.Location)
 	}
+	resourceGroupTime := time.Since(start)
 
-	containerGroup := buildContainerGroup(config)
+	if err := d.ensureFileShares(ctx, config.Storage, fileShares(config)); err != nil {
+		return DeployResult{}, deployError(err, config.Location)
+	}
+
+	containerGroup, err := buildContainerGroup(config)
+	if err != nil {
+		return DeployResult{}, err
+	}
 
 	var result armcontainerinstance.ContainerGroupsClientCreateOrUpdateResponse
 
 	operation := func() error {
-		poller, err := d.containerClient.BeginCreateOrUpdate(ctx, config.ResourceGroup, config.Name, containerGroup, nil)
+		poller, err := d.containerGroups().BeginCreateOrUpdate(ctx, config.ResourceGroup, config.Name, containerGroup, nil)
 		if err != nil {
 			if isPermanentError(err) {
 				return backoff.Permanent(err)
@@ -101,37 +266,108 @@ func (d *Deployer) Deploy(ctx context.Context, config DeployConfig) (string, err
 
 		res, err := poller.PollUntilDone(ctx, nil)
 		if err != nil {
-			return fmt.Errorf("failed to wait for container group: %w", err)
+			err = fmt.Errorf("failed to wait for container group: %w", err)
+			if quotaError(err, config.Location) != nil {
+				return backoff.Permanent(err)
+			}
+			return err
 		}
 		result = res
 		return nil
 	}
 
-	if err := retryWithBackoff(ctx, operation); err != nil {
-		return "", err
+	start = time.Now()
+	if err := d.retry(ctx, operation); err != nil {
+		return DeployResult{}, deployError(err, config.Location)
+	}
+
+	deployed, err := deployResult(result.ContainerGroup)
+	if err != nil {
+		return DeployResult{}, err
 	}
+	deployed.ResourceGroupTime = resourceGroupTime
+	deployed.ContainerGroupTime = time.Since(start)
+	return deployed, nil
+}
 
-	return extractFQDN(result)
+// deployError returns the deployerr type that explains err, or err itself
+// when it is none of them.
+func deployError(err error, location string) error {
+	if quotaErr := quotaError(err, location); quotaErr != nil {
+		return quotaErr
+	}
+	if authErr := authError(err); authErr != nil {
+		return authErr
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &deployerr.TimeoutError{Operation: "container group deployment", Err: err}
+	}
+	return err
 }
 
-func buildContainerGroup(config DeployConfig) armcontainerinstance.ContainerGroup {
+func buildContainerGroup(config DeployConfig) (armcontainerinstance.ContainerGroup, error) {
+	if err := validateIngressService(config); err != nil {
+		return armcontainerinstance.ContainerGroup{}, err
+	}
+	var ingressPort *armcontainerinstance.Port
+	if config.Ingress != nil {
+		port, err := buildIngressPort(config)
+		if err != nil {
+			return armcontainerinstance.ContainerGroup{}, err
+		}
+		ingressPort = port
+	}
+
 	containers := make([]*armcontainerinstance.Container, 0, len(config.Containers))
+	var initContainers []*armcontainerinstance.InitContainerDefinition
 	exposedPorts := make([]*armcontainerinstance.Port, 0)
+	var totalCPU, totalMemoryGB float64
 
 	for _, c := range config.Containers {
-		ports := make([]*armcontainerinstance.ContainerPort, 0, len(c.Ports))
+		if c.Init {
+			initContainers = append(initContainers, &armcontainerinstance.InitContainerDefinition{
+				Name: to.Ptr(c.Name),
+				Properties: &armcontainerinstance.InitContainerPropertiesDefinition{
+					Image:                to.Ptr(c.Image),
+					Command:              buildCommand(c.Command),
+					EnvironmentVariables: buildEnvVars(c.Environment, config.SecretKeys),
+					VolumeMounts:         buildVolumeMounts(c.VolumeMounts),
+				},
+			})
+			continue
+		}
+
+		public := ingressPort == nil && c.Restart.LongRunning() && (config.IngressService == "" || c.Name == config.IngressService)
+
+		ports := make([]*armcontainerinstance.ContainerPort, 0, len(c.Ports)+len(c.UDPPorts))
 		for _, p := range c.Ports {
 			ports = append(ports, &armcontainerinstance.ContainerPort{
 				Port:     to.Ptr(p),
 				Protocol: to.Ptr(armcontainerinstance.ContainerNetworkProtocolTCP),
 			})
+			if !public {
+				continue
+			}
 			exposedPorts = append(exposedPorts, &armcontainerinstance.Port{
 				Port:     to.Ptr(p),
 				Protocol: to.Ptr(armcontainerinstance.ContainerGroupNetworkProtocolTCP),
 			})
 		}
+		for _, p := range c.UDPPorts {
+			ports = append(ports, &armcontainerinstance.ContainerPort{
+				Port:     to.Ptr(p),
+				Protocol: to.Ptr(armcontainerinstance.ContainerNetworkProtocolUDP),
+			})
+			if !public {
+				continue
+			}
+			exposedPorts = append(exposedPorts, &armcontainerinstance.Port{
+				Port:     to.Ptr(p),
+				Protocol: to.Ptr(armcontainerinstance.ContainerGroupNetworkProtocolUDP),
+			})
+		}
 
-		envVars := buildEnvVars(c.Environment)
+		envVars := buildEnvVars(c.Environment, config.SecretKeys)
 
 		cpu := c.CPU
 		if cpu == 0 {
@@ -141,13 +377,23 @@ func buildContainerGroup(config DeployConfig) armcontainerinstance.ContainerGrou
 		if mem == 0 {
 			mem = DefaultMemoryGB
 		}
+		cpu, mem, err := NormalizeResources(cpu, mem)
+		if err != nil {
+			return armcontainerinstance.ContainerGroup{}, fmt.Errorf("service %q: %w", c.Name, err)
+		}
+		totalCPU += cpu
+		totalMemoryGB += mem
 
 		containers = append(containers, &armcontainerinstance.Container{
 			Name: to.Ptr(c.Name),
 			Properties: &armcontainerinstance.ContainerProperties{
 				Image:                to.Ptr(c.Image),
+				Command:              buildCommand(c.Command),
 				Ports:                ports,
 				EnvironmentVariables: envVars,
+				LivenessProbe:        buildLivenessProbe(c.Probe, config.StartupGraceSeconds),
+				ReadinessProbe:       buildProbe(c.Probe),
+				VolumeMounts:         buildVolumeMounts(c.VolumeMounts),
 				Resources: &armcontainerinstance.ResourceRequirements{
 					Requests: &armcontainerinstance.ResourceRequests{
 						CPU:        to.Ptr(cpu),
@@ -157,23 +403,97 @@ func buildContainerGroup(config DeployConfig) armcontainerinstance.ContainerGrou
 			},
 		})
 	}
+	if totalCPU > MaxCPU+resourceEpsilon || totalMemoryGB > MaxMemoryGB+resourceEpsilon {
+		return armcontainerinstance.ContainerGroup{}, fmt.Errorf("containers request %.2f vCPU / %.1f GB in total, over the container group limit of %.0f vCPU / %.0f GB", totalCPU, totalMemoryGB, MaxCPU, MaxMemoryGB)
+	}
+	if ingressPort != nil {
+		exposedPorts = append(exposedPorts, ingressPort)
+	}
 
 	return armcontainerinstance.ContainerGroup{
 		Location: to.Ptr(config.Location),
+		Tags:     buildTags(config.Tags),
 		Properties: &armcontainerinstance.ContainerGroupPropertiesProperties{
-			Containers:    containers,
-			OSType:        to.Ptr(armcontainerinstance.OperatingSystemTypesLinux),
-			RestartPolicy: to.Ptr(armcontainerinstance.ContainerGroupRestartPolicyAlways),
+			Containers:               containers,
+			InitContainers:           initContainers,
+			ImageRegistryCredentials: buildRegistryCredentials(config.RegistryCredentials),
+			Volumes:                  buildVolumes(config.Containers, config.Storage, config.ResourceGroup),
+			OSType:                   to.Ptr(armcontainerinstance.OperatingSystemTypesLinux),
+			RestartPolicy:            to.Ptr(groupRestartPolicy(config.Containers)),
 			IPAddress: &armcontainerinstance.IPAddress{
 				Type:         to.Ptr(armcontainerinstance.ContainerGroupIPAddressTypePublic),
 				Ports:        exposedPorts,
 				DNSNameLabel: to.Ptr(config.DNSNameLabel),
 			},
 		},
+	}, nil
+}
+
+func validateIngressService(config DeployConfig) error {
+	if config.IngressService == "" {
+		return nil
+	}
+
+	for _, c := range config.Containers {
+		if c.Name != config.IngressService {
+			continue
+		}
+		if c.Init {
+			return fmt.Errorf("ingress service %q is an init container and cannot serve traffic", config.IngressService)
+		}
+		if len(c.Ports) == 0 && len(c.UDPPorts) == 0 {
+			return fmt.Errorf("ingress service %q exposes no ports", config.IngressService)
+		}
+		if !c.Restart.LongRunning() {
+			return fmt.Errorf("ingress service %q runs to completion and cannot serve traffic", config.IngressService)
+		}
+		return nil
+	}
+	return fmt.Errorf("ingress service %q is not a deployable service", config.IngressService)
+}
+
+func buildIngressPort(config DeployConfig) (*armcontainerinstance.Port, error) {
+	target := config.Ingress
+	if config.IngressService != "" && config.IngressService != target.Service {
+		return nil, fmt.Errorf("ingress service %q conflicts with ingress target %q", config.IngressService, target.Service)
+	}
+	if !target.External {
+		return nil, fmt.Errorf("ingress target %q must be external: Container Instances previews are reached through a public IP", target.Service)
+	}
+
+	var protocol armcontainerinstance.ContainerGroupNetworkProtocol
+	switch strings.ToLower(target.Transport) {
+	case "", "tcp":
+		protocol = armcontainerinstance.ContainerGroupNetworkProtocolTCP
+	case "udp":
+		protocol = armcontainerinstance.ContainerGroupNetworkProtocolUDP
+	default:
+		return nil, fmt.Errorf("ingress target %q has unsupported transport %q, want tcp or udp", target.Service, target.Transport)
+	}
+
+	for _, c := range config.Containers {
+		if c.Name != target.Service {
+			continue
+		}
+		if c.Init {
+			return nil, fmt.Errorf("ingress service %q is an init container and cannot serve traffic", target.Service)
+		}
+		if !c.Restart.LongRunning() {
+			return nil, fmt.Errorf("ingress service %q runs to completion and cannot serve traffic", target.Service)
+		}
+		ports := c.Ports
+		if protocol == armcontainerinstance.ContainerGroupNetworkProtocolUDP {
+			ports = c.UDPPorts
+		}
+		if !slices.Contains(ports, target.Port) {
+			return nil, fmt.Errorf("ingress port %d/%s is not exposed by service %q", target.Port, strings.ToLower(string(protocol)), target.Service)
+		}
+		return &armcontainerinstance.Port{Port: to.Ptr(target.Port), Protocol: to.Ptr(protocol)}, nil
 	}
+	return nil, fmt.Errorf("ingress service %q is not a deployable service", target.Service)
 }
 
-func buildEnvVars(env map[string]string) []*armcontainerinstance.EnvironmentVariable {
+func buildEnvVars(env map[string]string, secretKeys []string) []*armcontainerinstance.EnvironmentVariable {
 	if len(env) == 0 {
 		return nil
 	}
@@ -186,30 +506,78 @@ func buildEnvVars(env map[string]string) []*armcontainerinstance.EnvironmentVari
 
 	envVars := make([]*armcontainerinstance.EnvironmentVariable, 0, len(env))
 	for _, k := range keys {
-		envVars = append(envVars, &armcontainerinstance.EnvironmentVariable{
-			Name:  to.Ptr(k),
-			Value: to.Ptr(env[k]),
-		})
+		envVar := &armcontainerinstance.EnvironmentVariable{Name: to.Ptr(k)}
+		if slices.Contains(secretKeys, k) {
+			envVar.SecureValue = to.Ptr(env[k])
+		} else {
+			envVar.Value = to.Ptr(env[k])
+		}
+		envVars = append(envVars, envVar)
 	}
 	return envVars
 }
 
-func extractFQDN(result armcontainerinstance.ContainerGroupsClientCreateOrUpdateResponse) (string, error) {
-	if result.Properties == nil {
-		return "", fmt.Errorf("container group has no properties")
+func buildCommand(command []string) []*string {
+	if len(command) == 0 {
+		return nil
+	}
+	return to.SliceOfPtrs(command...)
+}
+
+func buildRegistryCredentials(creds []RegistryCredential) []*armcontainerinstance.ImageRegistryCredential {
+	if len(creds) == 0 {
+		return nil
+	}
+
+	result := make([]*armcontainerinstance.ImageRegistryCredential, 0, len(creds))
+	for _, c := range creds {
+		result = append(result, &armcontainerinstance.ImageRegistryCredential{
+			Server:   to.Ptr(c.Server),
+			Username: to.Ptr(c.Username),
+			Password: to.Ptr(c.Password),
+		})
+	}
+	return result
+}
+
+func buildTags(tags map[string]string) map[string]*string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	result := make(map[string]*string, len(tags))
+	for k, v := range tags {
+		result[k] = to.Ptr(v)
+	}
+	return result
+}
+
+func deployResult(group armcontainerinstance.ContainerGroup) (DeployResult, error) {
+	if group.Properties == nil {
+		return DeployResult{}, fmt.Errorf("container group has no properties")
+	}
+	if group.Properties.IPAddress == nil {
+		return DeployResult{}, fmt.Errorf("container group has no IP address")
 	}
-	if result.Properties.IPAddress == nil {
-		return "", fmt.Errorf("container group has no IP address")
+	if group.Properties.IPAddress.Fqdn == nil {
+		return DeployResult{}, fmt.Errorf("container group has no FQDN")
 	}
-	if result.Properties.IPAddress.Fqdn == nil {
-		return "", fmt.Errorf("container group has no FQDN")
+	result := DeployResult{FQDN: *group.Properties.IPAddress.Fqdn}
+	if group.Properties.IPAddress.IP != nil {
+		result.IPAddress = *group.Properties.IPAddress.IP
 	}
-	return *result.Properties.IPAddress.Fqdn, nil
+	if group.Properties.ProvisioningState != nil {
+		result.ProvisioningState = *group.Properties.ProvisioningState
+	}
+	if group.ID != nil {
+		result.ContainerGroupID = *group.ID
+	}
+	return result, nil
 }
 
 func (d *Deployer) Delete(ctx context.Context, resourceGroup, name string) error {
 	operation := func() error {
-		poller, err := d.containerClient.BeginDelete(ctx, resourceGroup, name, nil)
+		poller, err := d.containerGroups().BeginDelete(ctx, resourceGroup, name, nil)
 		if err != nil {
 			if isPermanentError(err) {
 				return backoff.Permanent(err)
@@ -224,12 +592,12 @@ func (d *Deployer) Delete(ctx context.Context, resourceGroup, name string) error
 		return nil
 	}
 
-	return retryWithBackoff(ctx, operation)
+	return d.retry(ctx, operation)
 }
 
 func (d *Deployer) DeleteResourceGroup(ctx context.Context, name string) error {
 	operation := func() error {
-		poller, err := d.rgClient.BeginDelete(ctx, name, nil)
+		poller, err := d.resourceGroups().BeginDelete(ctx, name, nil)
 		if err != nil {
 			if isPermanentError(err) {
 				return backoff.Permanent(err)
@@ -244,27 +612,43 @@ func (d *Deployer) DeleteResourceGroup(ctx context.Context, name string) error {
 		return nil
 	}
 
-	return retryWithBackoff(ctx, operation)
+	return d.retry(ctx, operation)
 }
 
-func retryWithBackoff(ctx context.Context, operation func() error) error {
-	expBackoff := backoff.NewExponentialBackOff()
-	expBackoff.MaxElapsedTime = maxRetryTime
-	return backoff.Retry(operation, backoff.WithContext(expBackoff, ctx))
+func (d *Deployer) Teardown(ctx context.Context, resourceGroup, name string) (bool, error) {
+	if err := d.Delete(ctx, resourceGroup, name); err != nil && !IsNotFound(err) {
+		return false, err
+	}
+
+	if err := d.DeleteResourceGroup(ctx, resourceGroup); err != nil {
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
-func isPermanentError(err error) bool {
-	errStr := err.Error()
-	permanentErrors := []string{
-		"InvalidParameter",
-		"InvalidResourceGroup",
-		"AuthorizationFailed",
-		"InvalidSubscriptionId",
-	}
-	for _, pe := range permanentErrors {
-		if strings.Contains(errStr, pe) {
-			return true
+func (d *Deployer) ListExpiredResourceGroups(ctx context.Context, prefix string, now time.Time) ([]string, error) {
+	pager := d.resourceGroups().NewListPager(&armresources.ResourceGroupsClientListOptions{
+		Filter: to.Ptr(fmt.Sprintf("tagName eq '%s' and tagValue eq 'true'", TagManaged)),
+	})
+
+	var expired []string
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resource groups: %w", err)
+		}
+
+		for _, rg := range page.Value {
+			if rg.Name == nil || !strings.HasPrefix(*rg.Name, prefix) {
+				continue
+			}
+			if isExpired(rg.Tags, now) {
+				expired = append(expired, *rg.Name)
+			}
 		}
 	}
-	return false
+	return expired, nil
 }
@@ -1,16 +1,24 @@
 package azure
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
 	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2"
+	cifake "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	rgfake "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/fake"
+	"github.com/LoriKarikari/draftdeploy/internal/deployerr"
 )
 
 func TestNewDeployer(t *testing.T) {
-	cred, err := NewCredential()
-	if err != nil {
-		t.Skipf("skipping test without Azure credentials: %v", err)
-	}
-
-	deployer, err := NewDeployer(cred, "test-subscription")
+	deployer, err := NewDeployer(&azfake.TokenCredential{}, "test-subscription", DefaultRetryPolicy())
 	if err != nil {
 		t.Fatalf("failed to create deployer: %v", err)
 	}
@@ -20,6 +28,133 @@ func TestNewDeployer(t *testing.T) {
 	}
 }
 
+func fakeResourceGroupsServer() *rgfake.ResourceGroupsServer {
+	return &rgfake.ResourceGroupsServer{
+		Get: func(ctx context.Context, resourceGroupName string, options *armresources.ResourceGroupsClientGetOptions) (resp azfake.Responder[armresources.ResourceGroupsClientGetResponse], errResp azfake.ErrorResponder) {
+			errResp.SetResponseError(http.StatusNotFound, "ResourceGroupNotFound")
+			return
+		},
+		CreateOrUpdate: func(ctx context.Context, resourceGroupName string, parameters armresources.ResourceGroup, options *armresources.ResourceGroupsClientCreateOrUpdateOptions) (resp azfake.Responder[armresources.ResourceGroupsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			resp.SetResponse(http.StatusOK, armresources.ResourceGroupsClientCreateOrUpdateResponse{
+				ResourceGroup: armresources.ResourceGroup{Name: to.Ptr(resourceGroupName), Location: parameters.Location},
+			}, nil)
+			return
+		},
+	}
+}
+
+func fakeContainerGroupsServer(calls *int, failures int, status int, code string) *cifake.ContainerGroupsServer {
+	return &cifake.ContainerGroupsServer{
+		BeginCreateOrUpdate: func(ctx context.Context, resourceGroupName, containerGroupName string, containerGroup armcontainerinstance.ContainerGroup, options *armcontainerinstance.ContainerGroupsClientBeginCreateOrUpdateOptions) (resp azfake.PollerResponder[armcontainerinstance.ContainerGroupsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			*calls++
+			if *calls <= failures {
+				errResp.SetResponseError(status, code)
+				return
+			}
+			containerGroup.ID = to.Ptr("/subscriptions/sub/resourceGroups/" + resourceGroupName + "/providers/Microsoft.ContainerInstance/containerGroups/" + containerGroupName)
+			containerGroup.Properties.ProvisioningState = to.Ptr("Succeeded")
+			containerGroup.Properties.IPAddress.Fqdn = to.Ptr(*containerGroup.Properties.IPAddress.DNSNameLabel + ".eastus.azurecontainer.io")
+			containerGroup.Properties.IPAddress.IP = to.Ptr("20.1.2.3")
+			resp.SetTerminalResponse(http.StatusOK, armcontainerinstance.ContainerGroupsClientCreateOrUpdateResponse{ContainerGroup: containerGroup}, nil)
+			return
+		},
+	}
+}
+
+func TestDeploy(t *testing.T) {
+	config := DeployConfig{
+		ResourceGroup: "draftdeploy-rg",
+		Name:          "dd-pr1",
+		Location:      "eastus",
+		DNSNameLabel:  "dd-pr1",
+		Containers: []ContainerConfig{
+			{Name: "web", Image: "nginx:alpine", Ports: []int32{80}, CPU: 0.5, MemoryGB: 0.5},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		failures  int
+		status    int
+		code      string
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "success", wantCalls: 1},
+		{name: "throttled then success", failures: 1, status: http.StatusTooManyRequests, code: "TooManyRequests", wantCalls: 2},
+		{name: "server error then success", failures: 2, status: http.StatusInternalServerError, code: "InternalServerError", wantCalls: 3},
+		{name: "forbidden", failures: 1, status: http.StatusForbidden, code: "AuthorizationFailed", wantCalls: 1, wantErr: true},
+		{name: "expired token then success", failures: 1, status: http.StatusUnauthorized, code: "ExpiredAuthenticationToken", wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			d := newFakeDeployer(t, fakeContainerGroupsServer(&calls, tt.failures, tt.status, tt.code), fakeResourceGroupsServer())
+
+			result, err := d.Deploy(context.Background(), config)
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d create calls, got %d", tt.wantCalls, calls)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if IsNotFound(err) || !isPermanentError(err) {
+					t.Errorf("expected permanent error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.FQDN != "dd-pr1.eastus.azurecontainer.io" || result.IPAddress != "20.1.2.3" || result.ProvisioningState != "Succeeded" {
+				t.Errorf("unexpected result %+v", result)
+			}
+			if result.ResourceGroupTime <= 0 || result.ContainerGroupTime <= 0 {
+				t.Errorf("expected both phase times to be recorded, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestDeploy_ErrorTypes(t *testing.T) {
+	config := DeployConfig{
+		ResourceGroup: "draftdeploy-rg",
+		Name:          "dd-pr1",
+		Location:      "eastus",
+		DNSNameLabel:  "dd-pr1",
+		Containers:    []ContainerConfig{{Name: "web", Image: "nginx:alpine", Ports: []int32{80}, CPU: 0.5, MemoryGB: 0.5}},
+	}
+
+	t.Run("forbidden", func(t *testing.T) {
+		calls := 0
+		d := newFakeDeployer(t, fakeContainerGroupsServer(&calls, 1, http.StatusForbidden, "AuthorizationFailed"), fakeResourceGroupsServer())
+
+		_, err := d.Deploy(context.Background(), config)
+		var authErr *deployerr.AuthError
+		if !errors.As(err, &authErr) {
+			t.Fatalf("expected *deployerr.AuthError, got %v", err)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		calls := 0
+		d := newFakeDeployer(t, fakeContainerGroupsServer(&calls, 0, 0, ""), fakeResourceGroupsServer())
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		_, err := d.Deploy(ctx, config)
+		var timeoutErr *deployerr.TimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("expected *deployerr.TimeoutError, got %v", err)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Error("expected timeout error to wrap the context error")
+		}
+	})
+}
+
 func TestDeployConfig(t *testing.T) {
 	config := DeployConfig{
 		ResourceGroup: "test-rg",
@@ -60,3 +195,408 @@ func TestDeployConfig(t *testing.T) {
 		t.Errorf("expected 1 container, got %d", len(config.Containers))
 	}
 }
+
+func TestBuildContainerGroup_IngressService(t *testing.T) {
+	config := DeployConfig{
+		Name:           "test-container",
+		Location:       "eastus",
+		DNSNameLabel:   "test-dns",
+		IngressService: "api",
+		Containers: []ContainerConfig{
+			{Name: "api", Image: "api:latest", Ports: []int32{3000}},
+			{Name: "web", Image: "nginx:alpine", Ports: []int32{80}},
+		},
+	}
+
+	group, err := buildContainerGroup(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exposed := group.Properties.IPAddress.Ports
+	if len(exposed) != 1 {
+		t.Fatalf("expected 1 exposed port, got %d", len(exposed))
+	}
+	if *exposed[0].Port != 3000 {
+		t.Errorf("expected exposed port 3000, got %d", *exposed[0].Port)
+	}
+}
+
+func TestBuildContainerGroup_IngressServiceErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		ingress string
+	}{
+		{"no ports", "worker"},
+		{"unknown service", "missing"},
+		{"runs to completion", "migrate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DeployConfig{
+				IngressService: tt.ingress,
+				Containers: []ContainerConfig{
+					{Name: "web", Image: "nginx:alpine", Ports: []int32{80}},
+					{Name: "worker", Image: "worker:latest"},
+					{Name: "migrate", Image: "api:latest", Ports: []int32{9000}, Restart: RestartNever},
+				},
+			}
+			if _, err := buildContainerGroup(config); err == nil {
+				t.Errorf("expected error for ingress service %q", tt.ingress)
+			}
+		})
+	}
+}
+
+func TestBuildContainerGroup_IngressTarget(t *testing.T) {
+	containers := []ContainerConfig{
+		{Name: "web", Image: "nginx:alpine", Ports: []int32{80, 443}},
+		{Name: "api", Image: "api:latest", Ports: []int32{3000, 9090}, UDPPorts: []int32{5353}},
+	}
+
+	tests := []struct {
+		name         string
+		ingress      *IngressTarget
+		wantPorts    []int32
+		wantProtocol armcontainerinstance.ContainerGroupNetworkProtocol
+	}{
+		{
+			name:         "explicit target",
+			ingress:      &IngressTarget{Service: "api", Port: 9090, External: true},
+			wantPorts:    []int32{9090},
+			wantProtocol: armcontainerinstance.ContainerGroupNetworkProtocolTCP,
+		},
+		{
+			name:         "udp target",
+			ingress:      &IngressTarget{Service: "api", Port: 5353, External: true, Transport: "UDP"},
+			wantPorts:    []int32{5353},
+			wantProtocol: armcontainerinstance.ContainerGroupNetworkProtocolUDP,
+		},
+		{
+			name:         "nil falls back to every public port",
+			wantPorts:    []int32{80, 443, 3000, 9090, 5353},
+			wantProtocol: armcontainerinstance.ContainerGroupNetworkProtocolTCP,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group, err := buildContainerGroup(DeployConfig{Ingress: tt.ingress, Containers: containers})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			exposed := group.Properties.IPAddress.Ports
+			got := make([]int32, 0, len(exposed))
+			for _, p := range exposed {
+				got = append(got, *p.Port)
+			}
+			if !slices.Equal(got, tt.wantPorts) {
+				t.Fatalf("expected exposed ports %v, got %v", tt.wantPorts, got)
+			}
+			if *exposed[0].Protocol != tt.wantProtocol {
+				t.Errorf("expected protocol %s, got %s", tt.wantProtocol, *exposed[0].Protocol)
+			}
+		})
+	}
+}
+
+func TestBuildContainerGroup_IngressTargetErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		ingressService string
+		ingress        IngressTarget
+	}{
+		{name: "port not exposed", ingress: IngressTarget{Service: "web", Port: 8080, External: true}},
+		{name: "port exposed over other transport", ingress: IngressTarget{Service: "web", Port: 80, External: true, Transport: "udp"}},
+		{name: "unknown transport", ingress: IngressTarget{Service: "web", Port: 80, External: true, Transport: "http2"}},
+		{name: "internal", ingress: IngressTarget{Service: "web", Port: 80}},
+		{name: "unknown service", ingress: IngressTarget{Service: "missing", Port: 80, External: true}},
+		{name: "runs to completion", ingress: IngressTarget{Service: "migrate", Port: 9000, External: true}},
+		{name: "conflicts with ingress service", ingressService: "migrate", ingress: IngressTarget{Service: "web", Port: 80, External: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DeployConfig{
+				IngressService: tt.ingressService,
+				Ingress:        &tt.ingress,
+				Containers: []ContainerConfig{
+					{Name: "web", Image: "nginx:alpine", Ports: []int32{80}},
+					{Name: "migrate", Image: "api:latest", Ports: []int32{9000}, Restart: RestartNever},
+				},
+			}
+			if _, err := buildContainerGroup(config); err == nil {
+				t.Errorf("expected error for ingress target %+v", tt.ingress)
+			}
+		})
+	}
+}
+
+func TestBuildContainerGroup_Restart(t *testing.T) {
+	tests := []struct {
+		name       string
+		containers []ContainerConfig
+		want       armcontainerinstance.ContainerGroupRestartPolicy
+		exposed    int
+	}{
+		{
+			name: "long-running",
+			containers: []ContainerConfig{
+				{Name: "web", Image: "nginx", Ports: []int32{80}},
+				{Name: "api", Image: "api", Ports: []int32{3000}, Restart: RestartAlways},
+			},
+			want:    armcontainerinstance.ContainerGroupRestartPolicyAlways,
+			exposed: 2,
+		},
+		{
+			name: "one-shot alongside web",
+			containers: []ContainerConfig{
+				{Name: "web", Image: "nginx", Ports: []int32{80}},
+				{Name: "migrate", Image: "api", Ports: []int32{9000}, Restart: RestartNever},
+			},
+			want:    armcontainerinstance.ContainerGroupRestartPolicyAlways,
+			exposed: 1,
+		},
+		{
+			name: "jobs only",
+			containers: []ContainerConfig{
+				{Name: "migrate", Image: "api", Restart: RestartNever},
+				{Name: "seed", Image: "api", Restart: RestartOnFailure},
+			},
+			want: armcontainerinstance.ContainerGroupRestartPolicyOnFailure,
+		},
+		{
+			name:       "single job",
+			containers: []ContainerConfig{{Name: "migrate", Image: "api", Restart: RestartNever}},
+			want:       armcontainerinstance.ContainerGroupRestartPolicyNever,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group, err := buildContainerGroup(DeployConfig{Containers: tt.containers})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := *group.Properties.RestartPolicy; got != tt.want {
+				t.Errorf("restart policy = %s, want %s", got, tt.want)
+			}
+			if got := len(group.Properties.IPAddress.Ports); got != tt.exposed {
+				t.Errorf("expected %d exposed ports, got %d", tt.exposed, got)
+			}
+		})
+	}
+}
+
+func TestBuildContainerGroup_RegistryCredentials(t *testing.T) {
+	config := DeployConfig{
+		Containers: []ContainerConfig{
+			{Name: "web", Image: "ghcr.io/acme/web:latest", Ports: []int32{80}},
+		},
+		RegistryCredentials: []RegistryCredential{
+			{Server: "ghcr.io", Username: "acme", Password: "secret"},
+		},
+	}
+
+	group, err := buildContainerGroup(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	creds := group.Properties.ImageRegistryCredentials
+	if len(creds) != 1 {
+		t.Fatalf("expected 1 registry credential, got %d", len(creds))
+	}
+	if *creds[0].Server != "ghcr.io" || *creds[0].Username != "acme" || *creds[0].Password != "secret" {
+		t.Errorf("unexpected registry credential: %s/%s", *creds[0].Server, *creds[0].Username)
+	}
+}
+
+func TestBuildContainerGroup_UDPPorts(t *testing.T) {
+	config := DeployConfig{
+		Containers: []ContainerConfig{
+			{Name: "game", Image: "game:latest", Ports: []int32{8080}, UDPPorts: []int32{7777}},
+		},
+	}
+
+	group, err := buildContainerGroup(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exposed := group.Properties.IPAddress.Ports
+	if len(exposed) != 2 {
+		t.Fatalf("expected 2 exposed ports, got %d", len(exposed))
+	}
+	if *exposed[1].Port != 7777 || *exposed[1].Protocol != armcontainerinstance.ContainerGroupNetworkProtocolUDP {
+		t.Errorf("expected port 7777 exposed over UDP")
+	}
+
+	containerPorts := group.Properties.Containers[0].Properties.Ports
+	if *containerPorts[1].Protocol != armcontainerinstance.ContainerNetworkProtocolUDP {
+		t.Errorf("expected container port 7777 to use UDP")
+	}
+}
+
+func TestBuildContainerGroup_Command(t *testing.T) {
+	config := DeployConfig{
+		Containers: []ContainerConfig{
+			{Name: "api", Image: "myapp:latest", Command: []string{"/app/bin", "server", "--flag"}},
+			{Name: "web", Image: "nginx:latest"},
+		},
+	}
+
+	group, err := buildContainerGroup(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	command := group.Properties.Containers[0].Properties.Command
+	if len(command) != 3 || *command[0] != "/app/bin" || *command[2] != "--flag" {
+		t.Errorf("unexpected command for api container")
+	}
+	if group.Properties.Containers[1].Properties.Command != nil {
+		t.Errorf("expected image default command for web container")
+	}
+}
+
+func TestBuildContainerGroup_InitContainers(t *testing.T) {
+	config := DeployConfig{
+		SecretKeys: []string{"DATABASE_PASSWORD"},
+		Containers: []ContainerConfig{
+			{Name: "db", Image: "postgres:16", Ports: []int32{5432}},
+			{Name: "migrate", Image: "api:latest", Command: []string{"./migrate", "up"}, Environment: map[string]string{"DATABASE_PASSWORD": "pass"}, Restart: RestartNever, Init: true},
+			{Name: "api", Image: "api:latest", Ports: []int32{3000}},
+		},
+	}
+
+	group, err := buildContainerGroup(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	props := group.Properties
+	if len(props.Containers) != 2 || *props.Containers[0].Name != "db" || *props.Containers[1].Name != "api" {
+		t.Fatalf("expected db and api as containers, got %d", len(props.Containers))
+	}
+	if len(props.InitContainers) != 1 || *props.InitContainers[0].Name != "migrate" {
+		t.Fatalf("expected migrate as the only init container, got %d", len(props.InitContainers))
+	}
+	initProps := props.InitContainers[0].Properties
+	if *initProps.Image != "api:latest" || len(initProps.Command) != 2 || *initProps.Command[1] != "up" {
+		t.Errorf("unexpected init container properties")
+	}
+	if env := initProps.EnvironmentVariables; len(env) != 1 || env[0].SecureValue == nil {
+		t.Errorf("expected secret environment variable on init container")
+	}
+	if got := *props.RestartPolicy; got != armcontainerinstance.ContainerGroupRestartPolicyAlways {
+		t.Errorf("restart policy = %s, want Always", got)
+	}
+	if got := len(props.IPAddress.Ports); got != 2 {
+		t.Errorf("expected 2 exposed ports, got %d", got)
+	}
+}
+
+func TestBuildContainerGroup_InitIngress(t *testing.T) {
+	config := DeployConfig{
+		IngressService: "migrate",
+		Containers: []ContainerConfig{
+			{Name: "web", Image: "nginx:alpine", Ports: []int32{80}},
+			{Name: "migrate", Image: "api:latest", Ports: []int32{9000}, Init: true},
+		},
+	}
+	if _, err := buildContainerGroup(config); err == nil {
+		t.Error("expected error for an init container as ingress service")
+	}
+}
+
+func TestBuildEnvVars_Secrets(t *testing.T) {
+	env := map[string]string{
+		"POSTGRES_DB":       "myapp",
+		"POSTGRES_PASSWORD": "pass",
+	}
+
+	envVars := buildEnvVars(env, []string{"POSTGRES_PASSWORD"})
+	if len(envVars) != 2 {
+		t.Fatalf("expected 2 env vars, got %d", len(envVars))
+	}
+
+	db, password := envVars[0], envVars[1]
+	if *db.Name != "POSTGRES_DB" || db.Value == nil || db.SecureValue != nil {
+		t.Errorf("expected POSTGRES_DB to be a plain value")
+	}
+	if *password.Name != "POSTGRES_PASSWORD" || password.Value != nil || password.SecureValue == nil {
+		t.Errorf("expected POSTGRES_PASSWORD to be a secure value")
+	}
+	if *password.SecureValue != "pass" {
+		t.Errorf("expected secure value to be preserved, got %s", *password.SecureValue)
+	}
+}
+
+func TestBuildEnvVars_Empty(t *testing.T) {
+	if envVars := buildEnvVars(nil, []string{"SECRET"}); envVars != nil {
+		t.Errorf("expected nil env vars, got %v", envVars)
+	}
+}
+
+func TestDeployResult(t *testing.T) {
+	group := armcontainerinstance.ContainerGroup{
+		ID: to.Ptr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ContainerInstance/containerGroups/dd-app"),
+		Properties: &armcontainerinstance.ContainerGroupPropertiesProperties{
+			ProvisioningState: to.Ptr("Succeeded"),
+			IPAddress: &armcontainerinstance.IPAddress{
+				Fqdn: to.Ptr("dd-app.eastus.azurecontainer.io"),
+				IP:   to.Ptr("20.1.2.3"),
+			},
+		},
+	}
+
+	result, err := deployResult(group)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := DeployResult{
+		FQDN:              "dd-app.eastus.azurecontainer.io",
+		IPAddress:         "20.1.2.3",
+		ProvisioningState: "Succeeded",
+		ContainerGroupID:  *group.ID,
+	}
+	if result != want {
+		t.Errorf("deployResult() = %+v, want %+v", result, want)
+	}
+
+	if _, err := deployResult(armcontainerinstance.ContainerGroup{Properties: &armcontainerinstance.ContainerGroupPropertiesProperties{}}); err == nil {
+		t.Error("expected error for container group without IP address")
+	}
+}
+
+func TestDeploy_KeepsCreatedTag(t *testing.T) {
+	rg := &fakeResourceGroup{exists: true, tags: map[string]*string{
+		TagManaged: to.Ptr("true"),
+		TagCreated: to.Ptr("2026-01-02T03:04:05Z"),
+		TagTTL:     to.Ptr("168h0m0s"),
+	}}
+	calls := 0
+	d := newFakeDeployer(t, fakeContainerGroupsServer(&calls, 0, 0, ""), rg.server())
+
+	_, err := d.Deploy(context.Background(), DeployConfig{
+		ResourceGroup: "draftdeploy-rg",
+		Name:          "dd-pr1",
+		Location:      "eastus",
+		DNSNameLabel:  "dd-pr1",
+		Containers:    []ContainerConfig{{Name: "web", Image: "nginx:alpine", Ports: []int32{80}, CPU: 0.5, MemoryGB: 0.5}},
+		Tags:          ManagedTags("acme", "app", 1, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), 24*time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, _ := tagValue(rg.tags, TagCreated); got != "2026-01-02T03:04:05Z" {
+		t.Errorf("created = %q, want the original creation time", got)
+	}
+	if got, _ := tagValue(rg.tags, TagTTL); got != "24h0m0s" {
+		t.Errorf("ttl = %q, want the new TTL", got)
+	}
+}
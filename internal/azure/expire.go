@@ -0,0 +1,66 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// ExpireAt re-tags a preview's resource group so the reaper deletes it once
+// at has passed. It reports false if the resource group does not exist.
+func (d *Deployer) ExpireAt(ctx context.Context, resourceGroup string, at time.Time) (bool, error) {
+	var existed bool
+
+	operation := func() error {
+		existing, err := d.resourceGroups().Get(ctx, resourceGroup, nil)
+		if err != nil {
+			if IsNotFound(err) {
+				existed = false
+				return nil
+			}
+			if isPermanentError(err) {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+
+		tags := maps.Clone(existing.Tags)
+		if tags == nil {
+			tags = make(map[string]*string)
+		}
+		created, ttl := expiryTags(existing.Tags, at, time.Now())
+		tags[TagCreated] = to.Ptr(created)
+		tags[TagTTL] = to.Ptr(ttl)
+
+		if _, err := d.resourceGroups().Update(ctx, resourceGroup, armresources.ResourceGroupPatchable{Tags: tags}, nil); err != nil {
+			if isPermanentError(err) {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+		existed = true
+		return nil
+	}
+
+	if err := d.retry(ctx, operation); err != nil {
+		return false, fmt.Errorf("failed to set expiry on %s: %w", resourceGroup, err)
+	}
+	return existed, nil
+}
+
+// expiryTags keeps the creation time when it is readable so the tags still
+// say when the preview was deployed, and stretches the TTL to reach at.
+func expiryTags(tags map[string]*string, at, now time.Time) (string, string) {
+	created := now.UTC()
+	if value, ok := tagValue(tags, TagCreated); ok {
+		if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+			created = parsed
+		}
+	}
+	return created.Format(time.RFC3339), at.Sub(created).Round(time.Second).String()
+}
@@ -0,0 +1,80 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	rgfake "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/fake"
+)
+
+func TestExpireAt(t *testing.T) {
+	created := time.Now().Add(-2 * time.Hour).UTC().Truncate(time.Second)
+	at := time.Now().Add(24 * time.Hour)
+
+	var updated map[string]*string
+	rgServer := &rgfake.ResourceGroupsServer{
+		Get: func(ctx context.Context, resourceGroupName string, options *armresources.ResourceGroupsClientGetOptions) (resp azfake.Responder[armresources.ResourceGroupsClientGetResponse], errResp azfake.ErrorResponder) {
+			resp.SetResponse(http.StatusOK, armresources.ResourceGroupsClientGetResponse{
+				ResourceGroup: armresources.ResourceGroup{
+					Name: to.Ptr(resourceGroupName),
+					Tags: map[string]*string{
+						TagManaged: to.Ptr("true"),
+						TagCreated: to.Ptr(created.Format(time.RFC3339)),
+						TagTTL:     to.Ptr("168h0m0s"),
+					},
+				},
+			}, nil)
+			return
+		},
+		Update: func(ctx context.Context, resourceGroupName string, parameters armresources.ResourceGroupPatchable, options *armresources.ResourceGroupsClientUpdateOptions) (resp azfake.Responder[armresources.ResourceGroupsClientUpdateResponse], errResp azfake.ErrorResponder) {
+			updated = parameters.Tags
+			resp.SetResponse(http.StatusOK, armresources.ResourceGroupsClientUpdateResponse{}, nil)
+			return
+		},
+	}
+
+	existed, err := newFakeDeployer(t, nil, rgServer).ExpireAt(context.Background(), "draftdeploy-rg", at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !existed {
+		t.Fatal("expected resource group to exist")
+	}
+	if *updated[TagManaged] != "true" || *updated[TagCreated] != created.Format(time.RFC3339) {
+		t.Errorf("expected existing tags to be kept, got %v", updated)
+	}
+	if isExpired(updated, at.Add(-time.Minute)) || !isExpired(updated, at.Add(time.Minute)) {
+		t.Errorf("expected preview to expire at %s, got ttl %s", at, *updated[TagTTL])
+	}
+}
+
+func TestExpireAt_NotFound(t *testing.T) {
+	rgServer := &rgfake.ResourceGroupsServer{
+		Get: func(ctx context.Context, resourceGroupName string, options *armresources.ResourceGroupsClientGetOptions) (resp azfake.Responder[armresources.ResourceGroupsClientGetResponse], errResp azfake.ErrorResponder) {
+			errResp.SetResponseError(http.StatusNotFound, "ResourceGroupNotFound")
+			return
+		},
+	}
+
+	existed, err := newFakeDeployer(t, nil, rgServer).ExpireAt(context.Background(), "draftdeploy-rg", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if existed {
+		t.Error("expected resource group to be reported as absent")
+	}
+}
+
+func TestExpiryTags_MissingCreated(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	created, ttl := expiryTags(nil, now.Add(24*time.Hour), now)
+	if created != "2025-01-01T12:00:00Z" || ttl != "24h0m0s" {
+		t.Errorf("expiryTags() = %s, %s", created, ttl)
+	}
+}
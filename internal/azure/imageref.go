@@ -0,0 +1,71 @@
+package azure
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const maxImageNameLen = 255
+
+var (
+	imageDomainPattern    = regexp.MustCompile(`^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*|\[[0-9a-fA-F:]+\])(?::[0-9]+)?$`)
+	imagePathPattern      = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	imageTagPattern       = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	imageDigestPattern    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+	imageSHA256HexPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// ValidateImageReference checks that ref has the form
+// [registry/]name[:tag][@digest], following the rules Docker and
+// Container Instances use to pull images.
+func ValidateImageReference(ref string) error {
+	if ref == "" {
+		return fmt.Errorf("empty image reference")
+	}
+
+	name, digest, hasDigest := strings.Cut(ref, "@")
+	if hasDigest {
+		if !imageDigestPattern.MatchString(digest) {
+			return fmt.Errorf("invalid image reference %q: malformed digest", ref)
+		}
+		if hex, ok := strings.CutPrefix(digest, "sha256:"); ok && !imageSHA256HexPattern.MatchString(hex) {
+			return fmt.Errorf("invalid image reference %q: sha256 digest must be 64 lowercase hex characters", ref)
+		}
+	}
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		tag := name[i+1:]
+		name = name[:i]
+		if !imageTagPattern.MatchString(tag) {
+			return fmt.Errorf("invalid image reference %q: malformed tag %q", ref, tag)
+		}
+	}
+
+	if name == "" {
+		return fmt.Errorf("invalid image reference %q: missing repository name", ref)
+	}
+	if len(name) > maxImageNameLen {
+		return fmt.Errorf("invalid image reference %q: repository name longer than %d characters", ref, maxImageNameLen)
+	}
+
+	components := strings.Split(name, "/")
+	if len(components) > 1 && isImageDomain(components[0]) {
+		if !imageDomainPattern.MatchString(components[0]) {
+			return fmt.Errorf("invalid image reference %q: malformed registry %q", ref, components[0])
+		}
+		components = components[1:]
+	}
+	for _, c := range components {
+		if !imagePathPattern.MatchString(c) {
+			return fmt.Errorf("invalid image reference %q: repository path %q must be lowercase letters, digits and separators", ref, c)
+		}
+	}
+	return nil
+}
+
+// isImageDomain reports whether the first path component names a registry
+// rather than a Docker Hub namespace.
+func isImageDomain(component string) bool {
+	return strings.ContainsAny(component, ".:[") || component == "localhost" || strings.ToLower(component) != component
+}
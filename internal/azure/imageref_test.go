@@ -0,0 +1,48 @@
+package azure
+
+import "testing"
+
+func TestValidateImageReference(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	valid := []string{
+		"nginx",
+		"nginx:alpine",
+		"nginx:1.27.0-alpine",
+		"library/nginx:latest",
+		"ghcr.io/acme/api:pr-42",
+		"myregistry.azurecr.io/team/app/api:v1",
+		"localhost:5000/app",
+		"registry.example.com:443/app:1.0",
+		"acme/api@" + digest,
+		"ghcr.io/acme/api:v1@" + digest,
+		"my_org/my-app__worker",
+	}
+	for _, ref := range valid {
+		if err := ValidateImageReference(ref); err != nil {
+			t.Errorf("ValidateImageReference(%q) = %v, want nil", ref, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"Nginx",
+		"acme/MyApp:latest",
+		"nginx:",
+		":latest",
+		"nginx:-bad",
+		"nginx:has space",
+		"acme//api",
+		"acme/api-",
+		"acme/api@sha256:abc",
+		"acme/api@sha256:" + "0123456789ABCDEF0123456789abcdef0123456789abcdef0123456789abcdef",
+		"acme/api@" + digest[len("sha256:"):],
+		"-registry.io/app",
+		"https://ghcr.io/acme/api",
+	}
+	for _, ref := range invalid {
+		if err := ValidateImageReference(ref); err == nil {
+			t.Errorf("ValidateImageReference(%q) = nil, want error", ref)
+		}
+	}
+}
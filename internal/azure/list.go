@@ -0,0 +1,87 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+type DeploymentSummary struct {
+	ResourceGroup string
+	PR            int
+	FQDN          string
+	Created       time.Time
+}
+
+func (d *Deployer) ListDeployments(ctx context.Context, owner, repo string) ([]DeploymentSummary, error) {
+	pager := d.resourceGroups().NewListPager(&armresources.ResourceGroupsClientListOptions{
+		Filter: to.Ptr(fmt.Sprintf("tagName eq '%s' and tagValue eq 'true'", TagManaged)),
+	})
+
+	repository := fmt.Sprintf("%s/%s", owner, repo)
+	var summaries []DeploymentSummary
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resource groups: %w", err)
+		}
+
+		for _, rg := range page.Value {
+			summary, ok := deploymentSummary(rg, repository)
+			if !ok {
+				continue
+			}
+
+			fqdn, err := d.FindFQDN(ctx, summary.ResourceGroup)
+			if err != nil {
+				return nil, err
+			}
+			summary.FQDN = fqdn
+			summaries = append(summaries, summary)
+		}
+	}
+
+	slices.SortFunc(summaries, func(a, b DeploymentSummary) int { return a.PR - b.PR })
+	return summaries, nil
+}
+
+func (d *Deployer) FindFQDN(ctx context.Context, resourceGroup string) (string, error) {
+	pager := d.containerGroups().NewListByResourceGroupPager(resourceGroup, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to list container groups in %s: %w", resourceGroup, err)
+		}
+
+		for _, group := range page.Value {
+			if group.Properties != nil && group.Properties.IPAddress != nil && group.Properties.IPAddress.Fqdn != nil {
+				return *group.Properties.IPAddress.Fqdn, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func deploymentSummary(rg *armresources.ResourceGroup, repository string) (DeploymentSummary, bool) {
+	if rg == nil || rg.Name == nil {
+		return DeploymentSummary{}, false
+	}
+	if tagged, ok := tagValue(rg.Tags, TagRepo); !ok || !strings.EqualFold(tagged, repository) {
+		return DeploymentSummary{}, false
+	}
+
+	summary := DeploymentSummary{ResourceGroup: *rg.Name}
+	if pr, ok := tagValue(rg.Tags, TagPR); ok {
+		summary.PR, _ = strconv.Atoi(pr)
+	}
+	if created, ok := tagValue(rg.Tags, TagCreated); ok {
+		summary.Created, _ = time.Parse(time.RFC3339, created)
+	}
+	return summary, true
+}
@@ -0,0 +1,46 @@
+package azure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+func TestDeploymentSummary(t *testing.T) {
+	rg := &armresources.ResourceGroup{
+		Name: to.Ptr("draftdeploy-acme-app-pr12"),
+		Tags: map[string]*string{
+			TagManaged: to.Ptr("true"),
+			TagRepo:    to.Ptr("Acme/App"),
+			TagPR:      to.Ptr("12"),
+			TagCreated: to.Ptr("2024-05-01T10:00:00Z"),
+		},
+	}
+
+	summary, ok := deploymentSummary(rg, "acme/app")
+	if !ok {
+		t.Fatal("expected resource group to match repository")
+	}
+	if summary.ResourceGroup != "draftdeploy-acme-app-pr12" || summary.PR != 12 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if !summary.Created.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected created time: %s", summary.Created)
+	}
+}
+
+func TestDeploymentSummary_OtherRepo(t *testing.T) {
+	rg := &armresources.ResourceGroup{
+		Name: to.Ptr("draftdeploy-acme-other-pr1"),
+		Tags: map[string]*string{TagRepo: to.Ptr("acme/other")},
+	}
+
+	if _, ok := deploymentSummary(rg, "acme/app"); ok {
+		t.Error("expected resource group of another repository to be skipped")
+	}
+	if _, ok := deploymentSummary(&armresources.ResourceGroup{Name: to.Ptr("untagged")}, "acme/app"); ok {
+		t.Error("expected untagged resource group to be skipped")
+	}
+}
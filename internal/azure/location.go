@@ -0,0 +1,55 @@
+package azure
+
+import (
+	"fmt"
+	"strings"
+)
+
+// supportedLocations lists regions where Azure Container Instances is
+// available. Keep entries lowercase and without spaces.
+var supportedLocations = []string{
+	"australiaeast",
+	"australiasoutheast",
+	"brazilsouth",
+	"canadacentral",
+	"canadaeast",
+	"centralindia",
+	"centralus",
+	"eastasia",
+	"eastus",
+	"eastus2",
+	"francecentral",
+	"germanywestcentral",
+	"italynorth",
+	"japaneast",
+	"japanwest",
+	"koreacentral",
+	"northcentralus",
+	"northeurope",
+	"norwayeast",
+	"polandcentral",
+	"southafricanorth",
+	"southcentralus",
+	"southeastasia",
+	"southindia",
+	"swedencentral",
+	"switzerlandnorth",
+	"uaenorth",
+	"uksouth",
+	"ukwest",
+	"westcentralus",
+	"westeurope",
+	"westus",
+	"westus2",
+	"westus3",
+}
+
+func ValidateLocation(location string) error {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(location), " ", ""))
+	for _, l := range supportedLocations {
+		if l == normalized {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported Azure location %q (examples of valid locations: eastus, westeurope, uksouth, australiaeast)", location)
+}
@@ -0,0 +1,25 @@
+package azure
+
+import "testing"
+
+func TestValidateLocation(t *testing.T) {
+	tests := []struct {
+		location string
+		wantErr  bool
+	}{
+		{"eastus", false},
+		{"WestEurope", false},
+		{"West US 2", false},
+		{"us-east-1", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			err := ValidateLocation(tt.location)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateLocation(%q) error = %v, wantErr %t", tt.location, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/LoriKarikari/draftdeploy/internal/deployerr"
+	"github.com/cenkalti/backoff/v4"
+)
+
+const (
+	TagLockHolder  = "draftdeploy-lock"
+	TagLockExpires = "draftdeploy-lock-expires"
+
+	lockPollInterval   = 5 * time.Second
+	lockPollMultiplier = 1.5
+)
+
+var ErrDeployLocked = errors.New("another deploy holds the lock")
+
+type LockedError struct {
+	Holder  string
+	Expires time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s: held by %s until %s", ErrDeployLocked, e.Holder, e.Expires.UTC().Format(time.RFC3339))
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrDeployLocked
+}
+
+// AcquireDeployLock waits up to wait for the resource group's deploy lock.
+// A missing resource group is created with tags, so it is found by list
+// and reap even if the deploy stops right after taking the lock.
+//
+// The lock is best-effort, not mutual exclusion: resource group tags have
+// no compare-and-swap, so two callers that both read the tags before
+// either writes can both take it. The confirming read only catches a
+// writer that finished first. Callers that need exclusion must serialize
+// runs themselves, e.g. with a GitHub Actions concurrency group.
+func (d *Deployer) AcquireDeployLock(ctx context.Context, resourceGroup, location, holder string, tags map[string]string, ttl, wait time.Duration) error {
+	operation := func() error {
+		err := d.tryAcquireDeployLock(ctx, resourceGroup, location, holder, tags, ttl)
+		if err == nil {
+			return nil
+		}
+		if wait <= 0 || (!errors.Is(err, ErrDeployLocked) && isPermanentError(err)) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}
+
+	policy := RetryPolicy{
+		MaxElapsedTime:  wait,
+		InitialInterval: lockPollInterval,
+		Multiplier:      lockPollMultiplier,
+		Notify:          d.retryPolicy.Notify,
+	}.withDefaults()
+	if err := retryWithBackoff(ctx, policy, operation); err != nil {
+		err = fmt.Errorf("failed to acquire deploy lock on %s: %w", resourceGroup, err)
+		if wait > 0 && errors.Is(err, ErrDeployLocked) {
+			return &deployerr.TimeoutError{Operation: "waiting for the deploy lock", Timeout: wait, Err: err}
+		}
+		return err
+	}
+	return nil
+}
+
+func (d *Deployer) tryAcquireDeployLock(ctx context.Context, resourceGroup, location, holder string, newTags map[string]string, ttl time.Duration) error {
+	tags := map[string]*string{}
+	existing, err := d.resourceGroups().Get(ctx, resourceGroup, nil)
+	switch {
+	case err == nil:
+		if current, expires, held := lockHolder(existing.Tags, time.Now()); held && current != holder {
+			return &LockedError{Holder: current, Expires: expires}
+		}
+		maps.Copy(tags, existing.Tags)
+		if existing.Location != nil {
+			location = *existing.Location
+		}
+	case IsNotFound(err):
+		maps.Copy(tags, buildTags(newTags))
+	default:
+		return err
+	}
+
+	tags[TagLockHolder] = to.Ptr(holder)
+	tags[TagLockExpires] = to.Ptr(time.Now().Add(ttl).UTC().Format(time.RFC3339))
+	if _, err := d.resourceGroups().CreateOrUpdate(ctx, resourceGroup, armresources.ResourceGroup{
+		Location: to.Ptr(location),
+		Tags:     tags,
+	}, nil); err != nil {
+		return err
+	}
+
+	confirmed, err := d.resourceGroups().Get(ctx, resourceGroup, nil)
+	if err != nil {
+		return err
+	}
+	if current, expires, held := lockHolder(confirmed.Tags, time.Now()); held && current != holder {
+		return &LockedError{Holder: current, Expires: expires}
+	}
+	return nil
+}
+
+func (d *Deployer) ReleaseDeployLock(ctx context.Context, resourceGroup, holder string) error {
+	existing, err := d.resourceGroups().Get(ctx, resourceGroup, nil)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read deploy lock on %s: %w", resourceGroup, err)
+	}
+
+	if current, ok := tagValue(existing.Tags, TagLockHolder); !ok || current != holder {
+		return nil
+	}
+
+	tags := maps.Clone(existing.Tags)
+	delete(tags, TagLockHolder)
+	delete(tags, TagLockExpires)
+	if _, err := d.resourceGroups().Update(ctx, resourceGroup, armresources.ResourceGroupPatchable{Tags: tags}, nil); err != nil {
+		return fmt.Errorf("failed to release deploy lock on %s: %w", resourceGroup, err)
+	}
+	return nil
+}
+
+func lockHolder(tags map[string]*string, now time.Time) (string, time.Time, bool) {
+	holder, ok := tagValue(tags, TagLockHolder)
+	if !ok || holder == "" {
+		return "", time.Time{}, false
+	}
+	value, ok := tagValue(tags, TagLockExpires)
+	if !ok {
+		return "", time.Time{}, false
+	}
+	expires, err := time.Parse(time.RFC3339, value)
+	if err != nil || !now.Before(expires) {
+		return "", time.Time{}, false
+	}
+	return holder, expires, true
+}
+
+func lockTags(tags map[string]*string) map[string]*string {
+	kept := make(map[string]*string)
+	for _, key := range []string{TagLockHolder, TagLockExpires} {
+		if v, ok := tags[key]; ok {
+			kept[key] = v
+		}
+	}
+	return kept
+}
@@ -0,0 +1,177 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	cifake "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	rgfake "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/fake"
+	"github.com/LoriKarikari/draftdeploy/internal/deployerr"
+)
+
+type fakeResourceGroup struct {
+	mu     sync.Mutex
+	exists bool
+	tags   map[string]*string
+}
+
+func (f *fakeResourceGroup) server() *rgfake.ResourceGroupsServer {
+	return &rgfake.ResourceGroupsServer{
+		Get: func(ctx context.Context, name string, options *armresources.ResourceGroupsClientGetOptions) (resp azfake.Responder[armresources.ResourceGroupsClientGetResponse], errResp azfake.ErrorResponder) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if !f.exists {
+				errResp.SetResponseError(http.StatusNotFound, "ResourceGroupNotFound")
+				return
+			}
+			resp.SetResponse(http.StatusOK, armresources.ResourceGroupsClientGetResponse{
+				ResourceGroup: armresources.ResourceGroup{Name: to.Ptr(name), Location: to.Ptr("eastus"), Tags: f.tags},
+			}, nil)
+			return
+		},
+		CreateOrUpdate: func(ctx context.Context, name string, parameters armresources.ResourceGroup, options *armresources.ResourceGroupsClientCreateOrUpdateOptions) (resp azfake.Responder[armresources.ResourceGroupsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.exists = true
+			f.tags = parameters.Tags
+			resp.SetResponse(http.StatusOK, armresources.ResourceGroupsClientCreateOrUpdateResponse{ResourceGroup: parameters}, nil)
+			return
+		},
+		Update: func(ctx context.Context, name string, parameters armresources.ResourceGroupPatchable, options *armresources.ResourceGroupsClientUpdateOptions) (resp azfake.Responder[armresources.ResourceGroupsClientUpdateResponse], errResp azfake.ErrorResponder) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.tags = parameters.Tags
+			resp.SetResponse(http.StatusOK, armresources.ResourceGroupsClientUpdateResponse{}, nil)
+			return
+		},
+	}
+}
+
+func TestDeployLock_AcquireAndRelease(t *testing.T) {
+	rg := &fakeResourceGroup{}
+	d := newFakeDeployer(t, &cifake.ContainerGroupsServer{}, rg.server())
+	ctx := context.Background()
+
+	if err := d.AcquireDeployLock(ctx, "draftdeploy-rg", "eastus", "run-1", nil, time.Minute, 0); err != nil {
+		t.Fatalf("unexpected error acquiring lock: %v", err)
+	}
+	if holder, _, held := lockHolder(rg.tags, time.Now()); !held || holder != "run-1" {
+		t.Fatalf("expected lock held by run-1, got %q (held=%t)", holder, held)
+	}
+
+	err := d.AcquireDeployLock(ctx, "draftdeploy-rg", "eastus", "run-2", nil, time.Minute, 0)
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.Holder != "run-1" {
+		t.Fatalf("expected lock held by run-1 to block run-2, got %v", err)
+	}
+	if !errors.Is(err, ErrDeployLocked) {
+		t.Errorf("expected error to match ErrDeployLocked")
+	}
+
+	if err := d.ReleaseDeployLock(ctx, "draftdeploy-rg", "run-2"); err != nil {
+		t.Fatalf("unexpected error releasing foreign lock: %v", err)
+	}
+	if _, _, held := lockHolder(rg.tags, time.Now()); !held {
+		t.Fatal("expected a foreign release to leave the lock in place")
+	}
+
+	if err := d.ReleaseDeployLock(ctx, "draftdeploy-rg", "run-1"); err != nil {
+		t.Fatalf("unexpected error releasing lock: %v", err)
+	}
+	if err := d.AcquireDeployLock(ctx, "draftdeploy-rg", "eastus", "run-2", nil, time.Minute, 0); err != nil {
+		t.Fatalf("expected run-2 to acquire the released lock, got %v", err)
+	}
+}
+
+func TestDeployLock_CreatesManagedGroup(t *testing.T) {
+	rg := &fakeResourceGroup{}
+	d := newFakeDeployer(t, &cifake.ContainerGroupsServer{}, rg.server())
+	tags := ManagedTags("acme", "app", 1, time.Now(), time.Hour)
+
+	if err := d.AcquireDeployLock(context.Background(), "draftdeploy-rg", "eastus", "run-1", tags, time.Minute, 0); err != nil {
+		t.Fatalf("unexpected error acquiring lock: %v", err)
+	}
+	for key, want := range tags {
+		if got, _ := tagValue(rg.tags, key); got != want {
+			t.Errorf("tag %s = %q, want %q", key, got, want)
+		}
+	}
+	if holder, _, held := lockHolder(rg.tags, time.Now()); !held || holder != "run-1" {
+		t.Errorf("expected lock held by run-1, got %q (held=%t)", holder, held)
+	}
+}
+
+func TestDeployLock_WaitTimeout(t *testing.T) {
+	rg := &fakeResourceGroup{
+		exists: true,
+		tags: map[string]*string{
+			TagLockHolder:  to.Ptr("run-1"),
+			TagLockExpires: to.Ptr(time.Now().Add(time.Hour).UTC().Format(time.RFC3339)),
+		},
+	}
+	d := newFakeDeployer(t, &cifake.ContainerGroupsServer{}, rg.server())
+
+	err := d.AcquireDeployLock(context.Background(), "draftdeploy-rg", "eastus", "run-2", nil, time.Minute, 10*time.Millisecond)
+	var timeoutErr *deployerr.TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Timeout != 10*time.Millisecond {
+		t.Fatalf("expected lock wait to time out, got %v", err)
+	}
+	if !errors.Is(err, ErrDeployLocked) {
+		t.Error("expected error to match ErrDeployLocked")
+	}
+}
+
+func TestDeployLock_Expired(t *testing.T) {
+	rg := &fakeResourceGroup{
+		exists: true,
+		tags: map[string]*string{
+			TagLockHolder:  to.Ptr("crashed-run"),
+			TagLockExpires: to.Ptr(time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)),
+			TagManaged:     to.Ptr("true"),
+		},
+	}
+	d := newFakeDeployer(t, &cifake.ContainerGroupsServer{}, rg.server())
+
+	if err := d.AcquireDeployLock(context.Background(), "draftdeploy-rg", "eastus", "run-2", nil, time.Minute, 0); err != nil {
+		t.Fatalf("expected expired lock to be taken over, got %v", err)
+	}
+	if holder, _, _ := lockHolder(rg.tags, time.Now()); holder != "run-2" {
+		t.Errorf("expected run-2 to hold the lock, got %q", holder)
+	}
+	if v, ok := tagValue(rg.tags, TagManaged); !ok || v != "true" {
+		t.Error("expected existing tags to be preserved")
+	}
+}
+
+func TestLockHolder(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	future := now.Add(time.Minute).Format(time.RFC3339)
+	past := now.Add(-time.Minute).Format(time.RFC3339)
+
+	tests := []struct {
+		name string
+		tags map[string]*string
+		want bool
+	}{
+		{"held", map[string]*string{TagLockHolder: to.Ptr("run-1"), TagLockExpires: to.Ptr(future)}, true},
+		{"expired", map[string]*string{TagLockHolder: to.Ptr("run-1"), TagLockExpires: to.Ptr(past)}, false},
+		{"no expiry", map[string]*string{TagLockHolder: to.Ptr("run-1")}, false},
+		{"bad expiry", map[string]*string{TagLockHolder: to.Ptr("run-1"), TagLockExpires: to.Ptr("soon")}, false},
+		{"untagged", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, held := lockHolder(tt.tags, now); held != tt.want {
+				t.Errorf("lockHolder() held = %t, want %t", held, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// GetContainerLogs returns the last lines of a container's log. Container
+// Instances only keeps the log of the current run, so after a restart the
+// previous crash is gone.
+func (d *Deployer) GetContainerLogs(ctx context.Context, resourceGroup, containerGroup, container string, lines int) (string, error) {
+	var opts *armcontainerinstance.ContainersClientListLogsOptions
+	if lines > 0 {
+		opts = &armcontainerinstance.ContainersClientListLogsOptions{Tail: to.Ptr(int32(lines))}
+	}
+
+	var logs string
+	operation := func() error {
+		resp, err := d.containers().ListLogs(ctx, resourceGroup, containerGroup, container, opts)
+		if err != nil {
+			if isPermanentError(err) {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+		if resp.Content != nil {
+			logs = *resp.Content
+		}
+		return nil
+	}
+
+	if err := d.retry(ctx, operation); err != nil {
+		return "", fmt.Errorf("failed to get logs for container %s: %w", container, err)
+	}
+	return logs, nil
+}
@@ -0,0 +1,76 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2"
+	cifake "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2/fake"
+)
+
+func withFakeContainers(t *testing.T, d *Deployer, server *cifake.ContainersServer) *Deployer {
+	t.Helper()
+
+	client, err := armcontainerinstance.NewContainersClient("sub", &azfake.TokenCredential{}, &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{Transport: cifake.NewContainersServerTransport(server)},
+	})
+	if err != nil {
+		t.Fatalf("failed to create containers client: %v", err)
+	}
+	d.containersClient = client
+	return d
+}
+
+func TestGetContainerLogs(t *testing.T) {
+	var gotTail *int32
+	server := &cifake.ContainersServer{
+		ListLogs: func(ctx context.Context, resourceGroupName, containerGroupName, containerName string, options *armcontainerinstance.ContainersClientListLogsOptions) (resp azfake.Responder[armcontainerinstance.ContainersClientListLogsResponse], errResp azfake.ErrorResponder) {
+			if resourceGroupName != "draftdeploy-rg" || containerGroupName != "dd-pr1" || containerName != "api" {
+				t.Errorf("unexpected target %s/%s/%s", resourceGroupName, containerGroupName, containerName)
+			}
+			if options != nil {
+				gotTail = options.Tail
+			}
+			resp.SetResponse(http.StatusOK, armcontainerinstance.ContainersClientListLogsResponse{
+				Logs: armcontainerinstance.Logs{Content: to.Ptr("listening on :3000\npanic: boom\n")},
+			}, nil)
+			return
+		},
+	}
+	d := withFakeContainers(t, newFakeDeployer(t, nil, nil), server)
+
+	logs, err := d.GetContainerLogs(context.Background(), "draftdeploy-rg", "dd-pr1", "api", 50)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if logs != "listening on :3000\npanic: boom\n" {
+		t.Errorf("unexpected logs %q", logs)
+	}
+	if gotTail == nil || *gotTail != 50 {
+		t.Errorf("expected tail of 50 lines, got %v", gotTail)
+	}
+}
+
+func TestGetContainerLogs_NotFound(t *testing.T) {
+	calls := 0
+	server := &cifake.ContainersServer{
+		ListLogs: func(ctx context.Context, resourceGroupName, containerGroupName, containerName string, options *armcontainerinstance.ContainersClientListLogsOptions) (resp azfake.Responder[armcontainerinstance.ContainersClientListLogsResponse], errResp azfake.ErrorResponder) {
+			calls++
+			errResp.SetResponseError(http.StatusNotFound, "ResourceNotFound")
+			return
+		},
+	}
+	d := withFakeContainers(t, newFakeDeployer(t, nil, nil), server)
+
+	if _, err := d.GetContainerLogs(context.Background(), "draftdeploy-rg", "dd-pr1", "api", 50); !IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected no retries for a missing container group, got %d calls", calls)
+	}
+}
@@ -0,0 +1,74 @@
+package azure
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2"
+)
+
+type ProbeConfig struct {
+	HTTPPath           string
+	HTTPPort           int32
+	Command            []string
+	PeriodSeconds      int32
+	TimeoutSeconds     int32
+	FailureThreshold   int32
+	StartPeriodSeconds int32
+}
+
+// Container Instances has no startup probe, so the start period delays the
+// liveness probe instead. The readiness probe starts right away because a
+// failing readiness check only holds back traffic.
+func buildLivenessProbe(probe *ProbeConfig, startupGraceSeconds int32) *armcontainerinstance.ContainerProbe {
+	result := buildProbe(probe)
+	if result == nil {
+		return nil
+	}
+
+	delay := probe.StartPeriodSeconds
+	if startupGraceSeconds > 0 {
+		delay = startupGraceSeconds
+	}
+	if delay > 0 {
+		result.InitialDelaySeconds = to.Ptr(delay)
+	}
+	return result
+}
+
+func buildProbe(probe *ProbeConfig) *armcontainerinstance.ContainerProbe {
+	if probe == nil {
+		return nil
+	}
+
+	result := &armcontainerinstance.ContainerProbe{}
+	switch {
+	case probe.HTTPPort != 0:
+		path := probe.HTTPPath
+		if path == "" {
+			path = "/"
+		}
+		result.HTTPGet = &armcontainerinstance.ContainerHTTPGet{
+			Port:   to.Ptr(probe.HTTPPort),
+			Path:   to.Ptr(path),
+			Scheme: to.Ptr(armcontainerinstance.SchemeHTTP),
+		}
+	case len(probe.Command) > 0:
+		command := make([]*string, 0, len(probe.Command))
+		for _, arg := range probe.Command {
+			command = append(command, to.Ptr(arg))
+		}
+		result.Exec = &armcontainerinstance.ContainerExec{Command: command}
+	default:
+		return nil
+	}
+
+	if probe.PeriodSeconds > 0 {
+		result.PeriodSeconds = to.Ptr(probe.PeriodSeconds)
+	}
+	if probe.TimeoutSeconds > 0 {
+		result.TimeoutSeconds = to.Ptr(probe.TimeoutSeconds)
+	}
+	if probe.FailureThreshold > 0 {
+		result.FailureThreshold = to.Ptr(probe.FailureThreshold)
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package azure
+
+import "testing"
+
+func TestBuildProbe_HTTP(t *testing.T) {
+	probe := buildProbe(&ProbeConfig{
+		HTTPPort:         8080,
+		HTTPPath:         "/healthz",
+		PeriodSeconds:    10,
+		TimeoutSeconds:   3,
+		FailureThreshold: 5,
+	})
+
+	if probe == nil || probe.HTTPGet == nil {
+		t.Fatal("expected HTTP probe")
+	}
+	if *probe.HTTPGet.Port != 8080 || *probe.HTTPGet.Path != "/healthz" {
+		t.Errorf("unexpected HTTP probe target: %d%s", *probe.HTTPGet.Port, *probe.HTTPGet.Path)
+	}
+	if *probe.PeriodSeconds != 10 || *probe.TimeoutSeconds != 3 || *probe.FailureThreshold != 5 {
+		t.Error("expected probe timing to be preserved")
+	}
+}
+
+func TestBuildProbe_Exec(t *testing.T) {
+	probe := buildProbe(&ProbeConfig{Command: []string{"pg_isready", "-U", "postgres"}})
+
+	if probe == nil || probe.Exec == nil {
+		t.Fatal("expected exec probe")
+	}
+	if len(probe.Exec.Command) != 3 || *probe.Exec.Command[0] != "pg_isready" {
+		t.Errorf("unexpected exec command")
+	}
+	if probe.PeriodSeconds != nil {
+		t.Error("expected unset period to use Azure default")
+	}
+}
+
+func TestBuildContainerGroup_Probes(t *testing.T) {
+	probe := &ProbeConfig{
+		HTTPPort:           8080,
+		HTTPPath:           "/healthz",
+		PeriodSeconds:      10,
+		FailureThreshold:   3,
+		StartPeriodSeconds: 60,
+	}
+	config := DeployConfig{
+		Containers: []ContainerConfig{
+			{Name: "api", Image: "api", Ports: []int32{8080}, Probe: probe},
+			{Name: "worker", Image: "worker"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		grace     int32
+		wantDelay int32
+	}{
+		{"start period", 0, 60},
+		{"grace override", 180, 180},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.StartupGraceSeconds = tt.grace
+			group, err := buildContainerGroup(config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			api := group.Properties.Containers[0].Properties
+			if api.LivenessProbe == nil || api.ReadinessProbe == nil {
+				t.Fatal("expected liveness and readiness probes")
+			}
+			if api.LivenessProbe.InitialDelaySeconds == nil || *api.LivenessProbe.InitialDelaySeconds != tt.wantDelay {
+				t.Errorf("expected liveness initial delay %d, got %v", tt.wantDelay, api.LivenessProbe.InitialDelaySeconds)
+			}
+			if api.ReadinessProbe.InitialDelaySeconds != nil {
+				t.Error("expected readiness probe to start without delay")
+			}
+			if *api.LivenessProbe.PeriodSeconds != 10 || *api.LivenessProbe.FailureThreshold != 3 {
+				t.Error("expected liveness probe to keep healthcheck timing")
+			}
+
+			worker := group.Properties.Containers[1].Properties
+			if worker.LivenessProbe != nil || worker.ReadinessProbe != nil {
+				t.Error("expected no probes without a healthcheck")
+			}
+		})
+	}
+}
+
+func TestBuildProbe_Empty(t *testing.T) {
+	if buildProbe(nil) != nil {
+		t.Error("expected nil probe for nil config")
+	}
+	if buildProbe(&ProbeConfig{}) != nil {
+		t.Error("expected nil probe for empty config")
+	}
+}
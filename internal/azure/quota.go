@@ -0,0 +1,36 @@
+package azure
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/LoriKarikari/draftdeploy/internal/deployerr"
+)
+
+// quotaResources names the quota behind each error code Azure returns when
+// a subscription runs out of it.
+var quotaResources = map[string]string{
+	"ContainerGroupQuotaReached": "container groups",
+	"ResourceGroupQuotaExceeded": "resource groups",
+	"QuotaExceeded":              "container instances",
+	"OperationNotAllowed":        "cores",
+}
+
+// quotaError returns a *deployerr.QuotaError for err if it is a quota
+// rejection and nil otherwise. OperationNotAllowed only counts when it
+// mentions a quota.
+func quotaError(err error, location string) *deployerr.QuotaError {
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) {
+		return nil
+	}
+	resource, ok := quotaResources[respErr.ErrorCode]
+	if !ok {
+		return nil
+	}
+	if respErr.ErrorCode == "OperationNotAllowed" && !strings.Contains(strings.ToLower(respErr.Error()), "quota") {
+		return nil
+	}
+	return &deployerr.QuotaError{Resource: resource, Location: location, Code: respErr.ErrorCode, Err: err}
+}
@@ -0,0 +1,103 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/LoriKarikari/draftdeploy/internal/deployerr"
+)
+
+func newResponseError(status int, code, message string) error {
+	body := fmt.Sprintf(`{"error":{"code":%q,"message":%q}}`, code, message)
+	return runtime.NewResponseError(&http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	})
+}
+
+func TestQuotaError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "container group quota",
+			err:  fmt.Errorf("failed to create container group: %w", newResponseError(http.StatusConflict, "ContainerGroupQuotaReached", "Resource type 'Microsoft.ContainerInstance/containerGroups' container group quota 'StandardCores' exceeded in region 'eastus'.")),
+			want: "Azure quota exceeded for container groups in eastus (ContainerGroupQuotaReached); request an increase or reduce concurrent previews",
+		},
+		{
+			name: "core quota",
+			err:  newResponseError(http.StatusConflict, "OperationNotAllowed", "Operation results in exceeding quota limits of Core. Maximum allowed: 10, Current in use: 10."),
+			want: "Azure quota exceeded for cores in eastus (OperationNotAllowed); request an increase or reduce concurrent previews",
+		},
+		{
+			name: "operation not allowed without quota",
+			err:  newResponseError(http.StatusConflict, "OperationNotAllowed", "The resource is locked."),
+		},
+		{
+			name: "other response error",
+			err:  newResponseError(http.StatusBadRequest, "InvalidParameter", "bad image"),
+		},
+		{
+			name: "plain error",
+			err:  errors.New("QuotaExceeded"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotaErr := quotaError(tt.err, "eastus")
+			if tt.want == "" {
+				if quotaErr != nil {
+					t.Fatalf("expected no quota error, got %v", quotaErr)
+				}
+				return
+			}
+			if quotaErr == nil {
+				t.Fatal("expected quota error")
+			}
+			if quotaErr.Error() != tt.want {
+				t.Errorf("Error() = %q, want %q", quotaErr.Error(), tt.want)
+			}
+			if !errors.Is(quotaErr, tt.err) {
+				t.Error("expected quota error to wrap the Azure error")
+			}
+			if !isPermanentError(tt.err) {
+				t.Error("expected quota error to be permanent")
+			}
+		})
+	}
+}
+
+func TestDeploy_QuotaExceeded(t *testing.T) {
+	calls := 0
+	d := newFakeDeployer(t, fakeContainerGroupsServer(&calls, 1, http.StatusConflict, "ContainerGroupQuotaReached"), fakeResourceGroupsServer())
+
+	_, err := d.Deploy(context.Background(), DeployConfig{
+		ResourceGroup: "draftdeploy-rg",
+		Name:          "dd-pr1",
+		Location:      "westeurope",
+		DNSNameLabel:  "dd-pr1",
+		Containers:    []ContainerConfig{{Name: "web", Image: "nginx:alpine", Ports: []int32{80}, CPU: 0.5, MemoryGB: 0.5}},
+	})
+
+	var quotaErr *deployerr.QuotaError
+	if !errors.As(err, &quotaErr) {
+		t.Fatalf("expected *QuotaError, got %v", err)
+	}
+	if quotaErr.Location != "westeurope" || quotaErr.Resource != "container groups" {
+		t.Errorf("unexpected quota error %+v", quotaErr)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 create call, got %d", calls)
+	}
+}
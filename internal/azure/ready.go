@@ -0,0 +1,56 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	readinessRequestTimeout  = 10 * time.Second
+	readinessInitialInterval = time.Second
+	readinessMultiplier      = 1.5
+)
+
+func (d *Deployer) WaitForReady(ctx context.Context, host, path string, timeout time.Duration) error {
+	return waitForReady(ctx, http.DefaultClient, host, path, RetryPolicy{
+		MaxElapsedTime:  timeout,
+		InitialInterval: readinessInitialInterval,
+		Multiplier:      readinessMultiplier,
+		Notify:          d.retryPolicy.Notify,
+	}.withDefaults())
+}
+
+func waitForReady(ctx context.Context, client *http.Client, host, path string, policy RetryPolicy) error {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	url := fmt.Sprintf("http://%s%s", host, path)
+
+	operation := func() error {
+		reqCtx, cancel := context.WithTimeout(ctx, readinessRequestTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to reach %s: %w", url, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+		}
+		return nil
+	}
+
+	if err := retryWithBackoff(ctx, policy, operation); err != nil {
+		return fmt.Errorf("preview not ready: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testReadinessPolicy(timeout time.Duration) RetryPolicy {
+	return RetryPolicy{MaxElapsedTime: timeout, InitialInterval: 10 * time.Millisecond}.withDefaults()
+}
+
+func TestWaitForReady(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	if err := waitForReady(context.Background(), server.Client(), host, "healthz", testReadinessPolicy(5*time.Second)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 requests, got %d", calls.Load())
+	}
+}
+
+func TestWaitForReady_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	err := waitForReady(context.Background(), server.Client(), host, "/", testReadinessPolicy(100*time.Millisecond))
+	if err == nil {
+		t.Fatal("expected error when the preview keeps returning 5xx")
+	}
+	if !strings.Contains(err.Error(), "502") {
+		t.Errorf("expected error to mention the status code, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package azure
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const DefaultDeleteConcurrency = 5
+
+// DeleteResourceGroups deletes resource groups with at most concurrency
+// deletes in flight. Results holds each attempted group's error, nil on
+// success; one failure does not stop the others. Once ctx is done no new
+// deletes start, the skipped groups are missing from the results and the
+// context error is returned.
+func (d *Deployer) DeleteResourceGroups(ctx context.Context, names []string, concurrency int) (map[string]error, error) {
+	if concurrency < 1 {
+		concurrency = DefaultDeleteConcurrency
+	}
+
+	var (
+		mu      sync.Mutex
+		results = make(map[string]error, len(names))
+	)
+	g := new(errgroup.Group)
+	g.SetLimit(concurrency)
+	for _, name := range names {
+		if ctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			err := d.DeleteResourceGroup(ctx, name)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = err
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return results, ctx.Err()
+}
@@ -0,0 +1,118 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	rgfake "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/fake"
+)
+
+func TestDeleteResourceGroups(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	rgServer := &rgfake.ResourceGroupsServer{
+		BeginDelete: func(ctx context.Context, resourceGroupName string, options *armresources.ResourceGroupsClientBeginDeleteOptions) (resp azfake.PollerResponder[armresources.ResourceGroupsClientDeleteResponse], errResp azfake.ErrorResponder) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				current := maxInFlight.Load()
+				if n <= current || maxInFlight.CompareAndSwap(current, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+
+			if resourceGroupName == "draftdeploy-bad" {
+				errResp.SetResponseError(http.StatusForbidden, "AuthorizationFailed")
+				return
+			}
+			resp.SetTerminalResponse(http.StatusOK, armresources.ResourceGroupsClientDeleteResponse{}, nil)
+			return
+		},
+	}
+
+	names := []string{"draftdeploy-a", "draftdeploy-b", "draftdeploy-bad", "draftdeploy-c", "draftdeploy-d", "draftdeploy-e"}
+	results, err := newFakeDeployer(t, nil, rgServer).DeleteResourceGroups(context.Background(), names, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(results) != len(names) {
+		t.Fatalf("expected a result for every group, got %d", len(results))
+	}
+	for _, name := range names {
+		if gotErr := results[name]; (gotErr != nil) != (name == "draftdeploy-bad") {
+			t.Errorf("unexpected result for %s: %v", name, gotErr)
+		}
+	}
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("expected at most 2 concurrent deletes, got %d", got)
+	}
+}
+
+func TestDeleteResourceGroups_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var (
+		mu      sync.Mutex
+		started []string
+	)
+	rgServer := &rgfake.ResourceGroupsServer{
+		BeginDelete: func(_ context.Context, resourceGroupName string, options *armresources.ResourceGroupsClientBeginDeleteOptions) (resp azfake.PollerResponder[armresources.ResourceGroupsClientDeleteResponse], errResp azfake.ErrorResponder) {
+			mu.Lock()
+			started = append(started, resourceGroupName)
+			mu.Unlock()
+			cancel()
+			resp.SetTerminalResponse(http.StatusOK, armresources.ResourceGroupsClientDeleteResponse{}, nil)
+			return
+		},
+	}
+
+	names := []string{"draftdeploy-a", "draftdeploy-b", "draftdeploy-c", "draftdeploy-d"}
+	results, err := newFakeDeployer(t, nil, rgServer).DeleteResourceGroups(ctx, names, 1)
+	if err == nil {
+		t.Fatal("expected context error")
+	}
+	if len(started) != 1 {
+		t.Errorf("expected no deletes to start after cancellation, started %v", started)
+	}
+	if len(results) >= len(names) {
+		t.Errorf("expected skipped groups to be missing from results, got %v", results)
+	}
+}
+
+func TestDeleteResourceGroups_ConcurrentReauthenticate(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		rejected = make(map[string]bool)
+	)
+	rgServer := &rgfake.ResourceGroupsServer{
+		BeginDelete: func(_ context.Context, resourceGroupName string, options *armresources.ResourceGroupsClientBeginDeleteOptions) (resp azfake.PollerResponder[armresources.ResourceGroupsClientDeleteResponse], errResp azfake.ErrorResponder) {
+			mu.Lock()
+			first := !rejected[resourceGroupName]
+			rejected[resourceGroupName] = true
+			mu.Unlock()
+			if first {
+				errResp.SetResponseError(http.StatusUnauthorized, "ExpiredAuthenticationToken")
+				return
+			}
+			resp.SetTerminalResponse(http.StatusOK, armresources.ResourceGroupsClientDeleteResponse{}, nil)
+			return
+		},
+	}
+
+	names := []string{"draftdeploy-a", "draftdeploy-b", "draftdeploy-c", "draftdeploy-d", "draftdeploy-e"}
+	results, err := newFakeDeployer(t, nil, rgServer).DeleteResourceGroups(context.Background(), names, len(names))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range names {
+		if results[name] != nil {
+			t.Errorf("expected %s to be deleted after reauthenticating, got %v", name, results[name])
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package azure
+
+import (
+	"fmt"
+	"math"
+)
+
+// Standard Linux limits for a container group in most regions. Memory is
+// requested in steps of 0.1 GB; CPU may be any fraction of a core.
+const (
+	MaxCPU          = 4.0
+	MaxMemoryGB     = 16.0
+	MinCPU          = 0.1
+	MinMemoryGB     = 0.1
+	memoryStepGB    = 0.1
+	resourceEpsilon = 1e-9
+)
+
+// NormalizeResources rounds a container's request up to values Container
+// Instances accepts, and fails if it cannot fit in a container group.
+func NormalizeResources(cpu, memoryGB float64) (float64, float64, error) {
+	if cpu < 0 || memoryGB < 0 {
+		return 0, 0, fmt.Errorf("invalid resources %.2f vCPU / %.2f GB: must not be negative", cpu, memoryGB)
+	}
+	if cpu > MaxCPU+resourceEpsilon || memoryGB > MaxMemoryGB+resourceEpsilon {
+		return 0, 0, fmt.Errorf("resources %.2f vCPU / %.2f GB exceed the container group limit of %.0f vCPU / %.0f GB", cpu, memoryGB, MaxCPU, MaxMemoryGB)
+	}
+
+	cpu = math.Max(math.Ceil(cpu*100-resourceEpsilon)/100, MinCPU)
+	memoryGB = math.Max(math.Ceil(memoryGB/memoryStepGB-resourceEpsilon)*memoryStepGB, MinMemoryGB)
+	return cpu, math.Round(memoryGB*10) / 10, nil
+}
@@ -0,0 +1,65 @@
+package azure
+
+import "testing"
+
+func TestNormalizeResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		cpu     float64
+		mem     float64
+		wantCPU float64
+		wantMem float64
+		wantErr bool
+	}{
+		{"defaults", 0.5, 0.5, 0.5, 0.5, false},
+		{"one core", 1, 1.5, 1, 1.5, false},
+		{"max", 4, 16, 4, 16, false},
+		{"memory rounded up", 0.5, 0.75, 0.5, 0.8, false},
+		{"memory in MiB", 0.25, 0.512, 0.25, 0.6, false},
+		{"cpu rounded up", 0.333, 1, 0.34, 1, false},
+		{"below minimum", 0.05, 0.05, 0.1, 0.1, false},
+		{"too much cpu", 8, 4, 0, 0, true},
+		{"too much memory", 1, 32, 0, 0, true},
+		{"negative", -1, 1, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpu, mem, err := NormalizeResources(tt.cpu, tt.mem)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %.2f vCPU / %.2f GB", tt.cpu, tt.mem)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cpu != tt.wantCPU || mem != tt.wantMem {
+				t.Errorf("NormalizeResources(%v, %v) = %v, %v, want %v, %v", tt.cpu, tt.mem, cpu, mem, tt.wantCPU, tt.wantMem)
+			}
+		})
+	}
+}
+
+func TestBuildContainerGroup_ResourceLimits(t *testing.T) {
+	config := DeployConfig{
+		Containers: []ContainerConfig{
+			{Name: "api", Image: "api", CPU: 2, MemoryGB: 0.75},
+			{Name: "db", Image: "postgres", CPU: 2, MemoryGB: 8},
+		},
+	}
+
+	group, err := buildContainerGroup(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := *group.Properties.Containers[0].Properties.Resources.Requests.MemoryInGB; got != 0.8 {
+		t.Errorf("expected memory to be rounded to 0.8 GB, got %v", got)
+	}
+
+	config.Containers = append(config.Containers, ContainerConfig{Name: "cache", Image: "redis", CPU: 1, MemoryGB: 1})
+	if _, err := buildContainerGroup(config); err == nil {
+		t.Error("expected error when the group exceeds the CPU limit")
+	}
+}
@@ -0,0 +1,175 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/cenkalti/backoff/v4"
+)
+
+const (
+	defaultMaxElapsedTime      = 2 * time.Minute
+	defaultInitialInterval     = backoff.DefaultInitialInterval
+	defaultMultiplier          = backoff.DefaultMultiplier
+	defaultRandomizationFactor = backoff.DefaultRandomizationFactor
+)
+
+type RetryPolicy struct {
+	MaxElapsedTime      time.Duration
+	InitialInterval     time.Duration
+	Multiplier          float64
+	RandomizationFactor float64
+	// Notify, if set, is called before each retry with the error and the delay.
+	Notify func(err error, next time.Duration)
+
+	// reauthenticate, if set, is called after an attempt fails with an
+	// expired token so the next attempt uses a fresh one.
+	reauthenticate func() error
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxElapsedTime:      defaultMaxElapsedTime,
+		InitialInterval:     defaultInitialInterval,
+		Multiplier:          defaultMultiplier,
+		RandomizationFactor: defaultRandomizationFactor,
+	}
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	defaults := DefaultRetryPolicy()
+	if p.MaxElapsedTime <= 0 {
+		p.MaxElapsedTime = defaults.MaxElapsedTime
+	}
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = defaults.InitialInterval
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaults.Multiplier
+	}
+	if p.RandomizationFactor <= 0 || p.RandomizationFactor > 1 {
+		p.RandomizationFactor = defaults.RandomizationFactor
+	}
+	return p
+}
+
+func (p RetryPolicy) newBackOff() *backoff.ExponentialBackOff {
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = p.MaxElapsedTime
+	expBackoff.InitialInterval = p.InitialInterval
+	expBackoff.Multiplier = p.Multiplier
+	expBackoff.RandomizationFactor = p.RandomizationFactor
+	expBackoff.Reset()
+	return expBackoff
+}
+
+func (d *Deployer) retry(ctx context.Context, operation func() error) error {
+	policy := d.retryPolicy
+	policy.reauthenticate = d.reauthenticate
+	return retryWithBackoff(ctx, policy, operation)
+}
+
+// retryWithBackoff gives up once the next wait would run past the context
+// deadline, returning the last error instead of waiting for the deadline.
+// This keeps short cleanup contexts from being spent entirely on retries.
+func retryWithBackoff(ctx context.Context, policy RetryPolicy, operation func() error) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		policy.MaxElapsedTime = max(min(policy.MaxElapsedTime, time.Until(deadline)), time.Nanosecond)
+	}
+	if policy.reauthenticate != nil {
+		operation = reauthenticating(operation, policy.reauthenticate)
+	}
+	return backoff.RetryNotify(operation, backoff.WithContext(policy.newBackOff(), ctx), policy.Notify)
+}
+
+func reauthenticating(operation func() error, reauthenticate func() error) func() error {
+	return func() error {
+		err := operation()
+		if err == nil || !isTokenExpired(err) {
+			return err
+		}
+		if refreshErr := reauthenticate(); refreshErr != nil {
+			return backoff.Permanent(fmt.Errorf("failed to refresh Azure credential: %w", errors.Join(err, refreshErr)))
+		}
+		return err
+	}
+}
+
+var permanentErrorCodes = []string{
+	"InvalidParameter",
+	"InvalidResourceGroup",
+	"AuthorizationFailed",
+	"InvalidSubscriptionId",
+	"QuotaExceeded",
+	"ContainerGroupQuotaReached",
+	"SkuNotAvailable",
+	"RegionNotAvailable",
+	"LocationNotAvailableForResourceType",
+	"InvalidTemplateDeployment",
+	"InaccessibleImage",
+	"ImageNotFound",
+	"RegistryErrorResponse",
+	"UNAUTHORIZED",
+	"MissingSubscriptionRegistration",
+}
+
+// tokenExpiredCodes come with a 401 when the bearer token has expired or
+// been revoked. Unlike a 403, a fresh token can fix them.
+var tokenExpiredCodes = []string{
+	"ExpiredAuthenticationToken",
+	"InvalidAuthenticationToken",
+}
+
+var transientConflictCodes = []string{
+	"AnotherOperationInProgress",
+	"ResourceGroupBeingDeleted",
+	"Conflict",
+}
+
+func IsNotFound(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
+
+func isTokenExpired(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusUnauthorized && slices.Contains(tokenExpiredCodes, respErr.ErrorCode)
+}
+
+func isPermanentError(err error) bool {
+	if quotaError(err, "") != nil {
+		return true
+	}
+	if isTokenExpired(err) {
+		return false
+	}
+
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.StatusCode {
+		case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+			return true
+		case http.StatusConflict:
+			return !slices.Contains(transientConflictCodes, respErr.ErrorCode)
+		case http.StatusTooManyRequests:
+			return false
+		}
+		if respErr.StatusCode >= http.StatusInternalServerError {
+			return false
+		}
+	}
+
+	errStr := err.Error()
+	for _, code := range permanentErrorCodes {
+		if strings.Contains(errStr, code) {
+			return true
+		}
+	}
+	return false
+}
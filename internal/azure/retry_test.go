@@ -0,0 +1,248 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/cenkalti/backoff/v4"
+)
+
+func TestRetryPolicy_WithDefaults(t *testing.T) {
+	policy := RetryPolicy{MaxElapsedTime: 10 * time.Minute}.withDefaults()
+
+	if policy.MaxElapsedTime != 10*time.Minute {
+		t.Errorf("expected explicit max elapsed time to be kept, got %s", policy.MaxElapsedTime)
+	}
+	if policy.InitialInterval != defaultInitialInterval {
+		t.Errorf("expected default initial interval, got %s", policy.InitialInterval)
+	}
+	if policy.Multiplier != defaultMultiplier {
+		t.Errorf("expected default multiplier, got %f", policy.Multiplier)
+	}
+	if policy.RandomizationFactor != defaultRandomizationFactor {
+		t.Errorf("expected default jitter, got %f", policy.RandomizationFactor)
+	}
+}
+
+func TestRetryPolicy_NewBackOff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxElapsedTime:      time.Minute,
+		InitialInterval:     time.Second,
+		Multiplier:          2,
+		RandomizationFactor: 0.3,
+	}
+
+	b := policy.newBackOff()
+	if b.MaxElapsedTime != time.Minute || b.InitialInterval != time.Second || b.Multiplier != 2 || b.RandomizationFactor != 0.3 {
+		t.Errorf("backoff does not reflect policy: %+v", b)
+	}
+}
+
+func TestRetryWithBackoff_Permanent(t *testing.T) {
+	policy := RetryPolicy{InitialInterval: time.Millisecond}.withDefaults()
+
+	attempts := 0
+	err := retryWithBackoff(context.Background(), policy, func() error {
+		attempts++
+		return backoff.Permanent(errors.New("InvalidParameter"))
+	})
+
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt for permanent error, got %d", attempts)
+	}
+}
+
+func TestRetryWithBackoff_Transient(t *testing.T) {
+	policy := RetryPolicy{InitialInterval: time.Millisecond}.withDefaults()
+
+	attempts := 0
+	err := retryWithBackoff(context.Background(), policy, func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("throttled")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryWithBackoff_Notify(t *testing.T) {
+	var notified []error
+	policy := RetryPolicy{
+		InitialInterval: time.Millisecond,
+		Notify:          func(err error, _ time.Duration) { notified = append(notified, err) },
+	}.withDefaults()
+
+	attempts := 0
+	err := retryWithBackoff(context.Background(), policy, func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("throttled")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(notified) != 2 {
+		t.Errorf("expected 2 retry notifications, got %d", len(notified))
+	}
+}
+
+func TestRetryWithBackoff_ContextDeadline(t *testing.T) {
+	policy := RetryPolicy{InitialInterval: time.Second, MaxElapsedTime: 10 * time.Minute}.withDefaults()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	transient := errors.New("throttled")
+	attempts := 0
+	start := time.Now()
+	err := retryWithBackoff(ctx, policy, func() error {
+		attempts++
+		return transient
+	})
+
+	if !errors.Is(err, transient) {
+		t.Errorf("expected last operation error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt when the next wait exceeds the deadline, got %d", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("expected retry loop to return promptly, took %s", elapsed)
+	}
+}
+
+func TestRetryWithBackoff_Reauthenticate(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantAttempts int
+		wantReauth   int
+		wantErr      bool
+	}{
+		{name: "expired token", err: newResponseError(http.StatusUnauthorized, "ExpiredAuthenticationToken", "token expired"), wantAttempts: 2, wantReauth: 1},
+		{name: "authorization failed", err: newResponseError(http.StatusForbidden, "AuthorizationFailed", "no access"), wantAttempts: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reauths := 0
+			policy := RetryPolicy{InitialInterval: time.Millisecond}.withDefaults()
+			policy.reauthenticate = func() error {
+				reauths++
+				return nil
+			}
+
+			attempts := 0
+			err := retryWithBackoff(context.Background(), policy, func() error {
+				attempts++
+				if attempts > 1 {
+					return nil
+				}
+				if isPermanentError(tt.err) {
+					return backoff.Permanent(tt.err)
+				}
+				return tt.err
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("retryWithBackoff() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+			if reauths != tt.wantReauth {
+				t.Errorf("expected %d reauthentications, got %d", tt.wantReauth, reauths)
+			}
+		})
+	}
+}
+
+func TestRetryWithBackoff_ReauthenticateFails(t *testing.T) {
+	policy := RetryPolicy{InitialInterval: time.Millisecond}.withDefaults()
+	refreshErr := errors.New("no credential")
+	policy.reauthenticate = func() error { return refreshErr }
+
+	expired := newResponseError(http.StatusUnauthorized, "ExpiredAuthenticationToken", "token expired")
+	attempts := 0
+	err := retryWithBackoff(context.Background(), policy, func() error {
+		attempts++
+		return expired
+	})
+
+	if !errors.Is(err, refreshErr) || !errors.Is(err, expired) {
+		t.Errorf("expected refresh and token errors, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt when refresh fails, got %d", attempts)
+	}
+}
+
+func TestIsPermanentError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"bad request", &azcore.ResponseError{StatusCode: http.StatusBadRequest, ErrorCode: "InvalidParameter"}, true},
+		{"unauthorized", &azcore.ResponseError{StatusCode: http.StatusUnauthorized}, true},
+		{"expired token", &azcore.ResponseError{StatusCode: http.StatusUnauthorized, ErrorCode: "ExpiredAuthenticationToken"}, false},
+		{"wrapped invalid token", fmt.Errorf("failed to create container group: %w", &azcore.ResponseError{StatusCode: http.StatusUnauthorized, ErrorCode: "InvalidAuthenticationToken"}), false},
+		{"forbidden", &azcore.ResponseError{StatusCode: http.StatusForbidden, ErrorCode: "AuthorizationFailed"}, true},
+		{"not found", &azcore.ResponseError{StatusCode: http.StatusNotFound}, true},
+		{"conflict", &azcore.ResponseError{StatusCode: http.StatusConflict, ErrorCode: "InvalidResourceGroupLocation"}, true},
+		{"operation in progress", &azcore.ResponseError{StatusCode: http.StatusConflict, ErrorCode: "AnotherOperationInProgress"}, false},
+		{"throttled", &azcore.ResponseError{StatusCode: http.StatusTooManyRequests}, false},
+		{"server error", &azcore.ResponseError{StatusCode: http.StatusInternalServerError}, false},
+		{"wrapped forbidden", fmt.Errorf("failed to create container group: %w", &azcore.ResponseError{StatusCode: http.StatusForbidden}), true},
+		{"quota string", errors.New("QuotaExceeded: container group quota reached"), true},
+		{"sku string", errors.New("SkuNotAvailable in region"), true},
+		{"image string", errors.New("InaccessibleImage: image 'foo' is not accessible"), true},
+		{"registry unauthorized", errors.New("UNAUTHORIZED: authentication required"), true},
+		{"network", errors.New("connection reset by peer"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPermanentError(tt.err); got != tt.want {
+				t.Errorf("isPermanentError(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"not found", &azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "ResourceGroupNotFound"}, true},
+		{"wrapped not found", fmt.Errorf("failed to delete resource group: %w", &azcore.ResponseError{StatusCode: http.StatusNotFound}), true},
+		{"forbidden", &azcore.ResponseError{StatusCode: http.StatusForbidden}, false},
+		{"plain", errors.New("ResourceGroupNotFound"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNotFound(tt.err); got != tt.want {
+				t.Errorf("IsNotFound(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package azure
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	RouterName  = "draftdeploy-router"
+	RouterImage = "nginx:1.27-alpine"
+	RouterPort  = 80
+
+	routerCPU      = 0.1
+	routerMemoryGB = 0.1
+	routerConfEnv  = "DRAFTDEPLOY_NGINX_CONF"
+)
+
+// Route sends requests under Path to a container listening on Port.
+type Route struct {
+	Path string
+	Port int32
+}
+
+// RouterContainer builds an nginx container that serves every route on
+// RouterPort. Containers in a group share localhost, so upstreams are
+// addressed by port. Paths are forwarded unchanged.
+func RouterContainer(routes []Route) ContainerConfig {
+	return ContainerConfig{
+		Name:        RouterName,
+		Image:       RouterImage,
+		Ports:       []int32{RouterPort},
+		Environment: map[string]string{routerConfEnv: nginxConfig(routes)},
+		CPU:         routerCPU,
+		MemoryGB:    routerMemoryGB,
+		Command: []string{
+			"/bin/sh", "-c",
+			fmt.Sprintf(`printf '%%s' "$%s" > /etc/nginx/conf.d/default.conf && exec nginx -g 'daemon off;'`, routerConfEnv),
+		},
+		Restart: RestartAlways,
+	}
+}
+
+func nginxConfig(routes []Route) string {
+	sorted := slices.Clone(routes)
+	slices.SortFunc(sorted, func(a, b Route) int { return cmp.Compare(a.Path, b.Path) })
+
+	var sb strings.Builder
+	sb.WriteString("map $http_upgrade $connection_upgrade {\n    default upgrade;\n    '' close;\n}\n\n")
+	fmt.Fprintf(&sb, "server {\n    listen %d;\n", RouterPort)
+	for _, r := range sorted {
+		if r.Path == "/" {
+			writeLocation(&sb, "/", r.Port)
+			continue
+		}
+		writeLocation(&sb, "= "+r.Path, r.Port)
+		writeLocation(&sb, r.Path+"/", r.Port)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func writeLocation(sb *strings.Builder, match string, port int32) {
+	fmt.Fprintf(sb, "    location %s {\n", match)
+	fmt.Fprintf(sb, "        proxy_pass http://127.0.0.1:%d;\n", port)
+	sb.WriteString("        proxy_http_version 1.1;\n")
+	sb.WriteString("        proxy_set_header Host $host;\n")
+	sb.WriteString("        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;\n")
+	sb.WriteString("        proxy_set_header X-Forwarded-Proto $scheme;\n")
+	sb.WriteString("        proxy_set_header Upgrade $http_upgrade;\n")
+	sb.WriteString("        proxy_set_header Connection $connection_upgrade;\n")
+	sb.WriteString("    }\n")
+}
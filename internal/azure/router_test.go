@@ -0,0 +1,50 @@
+package azure
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNginxConfig(t *testing.T) {
+	conf := nginxConfig([]Route{
+		{Path: "/", Port: 3000},
+		{Path: "/api", Port: 8080},
+	})
+
+	for _, want := range []string{
+		"listen 80;",
+		"location = /api {\n        proxy_pass http://127.0.0.1:8080;",
+		"location /api/ {\n        proxy_pass http://127.0.0.1:8080;",
+		"location / {\n        proxy_pass http://127.0.0.1:3000;",
+	} {
+		if !strings.Contains(conf, want) {
+			t.Errorf("expected config to contain %q, got:\n%s", want, conf)
+		}
+	}
+	if strings.Count(conf, "location ") != 3 {
+		t.Errorf("expected 3 locations, got:\n%s", conf)
+	}
+}
+
+func TestRouterContainer(t *testing.T) {
+	router := RouterContainer([]Route{{Path: "/", Port: 3000}})
+
+	group, err := buildContainerGroup(DeployConfig{
+		IngressService: router.Name,
+		Containers: []ContainerConfig{
+			{Name: "web", Image: "web:latest", Ports: []int32{3000}},
+			router,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exposed := group.Properties.IPAddress.Ports
+	if len(exposed) != 1 || *exposed[0].Port != RouterPort {
+		t.Errorf("expected only the router port to be public")
+	}
+	if router.Environment[routerConfEnv] == "" {
+		t.Error("expected router config in the environment")
+	}
+}
@@ -0,0 +1,109 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2"
+	"github.com/cenkalti/backoff/v4"
+)
+
+const (
+	GroupPending = "pending"
+	GroupRunning = "running"
+	GroupFailed  = "failed"
+)
+
+var errGroupPending = errors.New("container group is still starting")
+
+// GroupStatus is the live state of a container group once it is created.
+// State is the instance view state: Pending, Running, Succeeded, Failed or
+// Stopped.
+type GroupStatus struct {
+	ProvisioningState string
+	State             string
+}
+
+// Phase folds the provisioning and instance states into GroupPending,
+// GroupRunning or GroupFailed. A group of one-shot services that all
+// exited successfully counts as running.
+func (s GroupStatus) Phase() string {
+	switch {
+	case strings.EqualFold(s.ProvisioningState, "Failed"),
+		strings.EqualFold(s.State, "Failed"),
+		strings.EqualFold(s.State, "Stopped"):
+		return GroupFailed
+	case strings.EqualFold(s.State, "Running"), strings.EqualFold(s.State, "Succeeded"):
+		return GroupRunning
+	}
+	return GroupPending
+}
+
+func (d *Deployer) GetGroupStatus(ctx context.Context, resourceGroup, name string) (GroupStatus, error) {
+	var status GroupStatus
+	operation := func() error {
+		resp, err := d.containerGroups().Get(ctx, resourceGroup, name, nil)
+		if err != nil {
+			if isPermanentError(err) {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+		status = groupStatus(resp.Properties)
+		return nil
+	}
+
+	if err := d.retry(ctx, operation); err != nil {
+		return GroupStatus{}, fmt.Errorf("failed to get container group %s: %w", name, err)
+	}
+	return status, nil
+}
+
+// WaitForGroupStatus polls the container group until it leaves
+// GroupPending or the timeout passes. On timeout it returns the last status
+// it saw together with the error.
+func (d *Deployer) WaitForGroupStatus(ctx context.Context, resourceGroup, name string, timeout time.Duration) (GroupStatus, error) {
+	return d.waitForGroupStatus(ctx, resourceGroup, name, RetryPolicy{
+		MaxElapsedTime:  timeout,
+		InitialInterval: readinessInitialInterval,
+		Multiplier:      readinessMultiplier,
+		Notify:          d.retryPolicy.Notify,
+	}.withDefaults())
+}
+
+func (d *Deployer) waitForGroupStatus(ctx context.Context, resourceGroup, name string, policy RetryPolicy) (GroupStatus, error) {
+	var status GroupStatus
+	operation := func() error {
+		var err error
+		status, err = d.GetGroupStatus(ctx, resourceGroup, name)
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		if status.Phase() == GroupPending {
+			return errGroupPending
+		}
+		return nil
+	}
+
+	if err := retryWithBackoff(ctx, policy, operation); err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+func groupStatus(props *armcontainerinstance.ContainerGroupPropertiesProperties) GroupStatus {
+	var status GroupStatus
+	if props == nil {
+		return status
+	}
+	if props.ProvisioningState != nil {
+		status.ProvisioningState = *props.ProvisioningState
+	}
+	if props.InstanceView != nil && props.InstanceView.State != nil {
+		status.State = *props.InstanceView.State
+	}
+	return status
+}
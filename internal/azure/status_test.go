@@ -0,0 +1,98 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2"
+	cifake "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2/fake"
+)
+
+func TestGroupStatus_Phase(t *testing.T) {
+	tests := []struct {
+		status GroupStatus
+		want   string
+	}{
+		{GroupStatus{ProvisioningState: "Succeeded", State: "Running"}, GroupRunning},
+		{GroupStatus{ProvisioningState: "Succeeded", State: "Succeeded"}, GroupRunning},
+		{GroupStatus{ProvisioningState: "Succeeded", State: "Pending"}, GroupPending},
+		{GroupStatus{ProvisioningState: "Creating"}, GroupPending},
+		{GroupStatus{ProvisioningState: "Failed", State: "Running"}, GroupFailed},
+		{GroupStatus{ProvisioningState: "Succeeded", State: "Failed"}, GroupFailed},
+		{GroupStatus{ProvisioningState: "Succeeded", State: "Stopped"}, GroupFailed},
+		{GroupStatus{}, GroupPending},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.Phase(); got != tt.want {
+			t.Errorf("%+v.Phase() = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func fakeStatusServer(calls *int, states ...string) *cifake.ContainerGroupsServer {
+	return &cifake.ContainerGroupsServer{
+		Get: func(ctx context.Context, resourceGroupName, containerGroupName string, options *armcontainerinstance.ContainerGroupsClientGetOptions) (resp azfake.Responder[armcontainerinstance.ContainerGroupsClientGetResponse], errResp azfake.ErrorResponder) {
+			state := states[min(*calls, len(states)-1)]
+			*calls++
+			if state == "" {
+				errResp.SetResponseError(http.StatusNotFound, "ResourceNotFound")
+				return
+			}
+			resp.SetResponse(http.StatusOK, armcontainerinstance.ContainerGroupsClientGetResponse{
+				ContainerGroup: armcontainerinstance.ContainerGroup{
+					Properties: &armcontainerinstance.ContainerGroupPropertiesProperties{
+						ProvisioningState: to.Ptr("Succeeded"),
+						InstanceView:      &armcontainerinstance.ContainerGroupPropertiesInstanceView{State: to.Ptr(state)},
+					},
+				},
+			}, nil)
+			return
+		},
+	}
+}
+
+func TestWaitForGroupStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		states    []string
+		wantPhase string
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "running", states: []string{"Running"}, wantPhase: GroupRunning, wantCalls: 1},
+		{name: "pending then running", states: []string{"Pending", "Pending", "Running"}, wantPhase: GroupRunning, wantCalls: 3},
+		{name: "pending then failed", states: []string{"Pending", "Failed"}, wantPhase: GroupFailed, wantCalls: 2},
+		{name: "never settles", states: []string{"Pending"}, wantPhase: GroupPending, wantErr: true},
+		{name: "missing", states: []string{""}, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			d := newFakeDeployer(t, fakeStatusServer(&calls, tt.states...), nil)
+
+			status, err := d.waitForGroupStatus(context.Background(), "rg", "dd-pr1", RetryPolicy{
+				MaxElapsedTime:  50 * time.Millisecond,
+				InitialInterval: time.Millisecond,
+			}.withDefaults())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr && tt.wantPhase == GroupPending && !errors.Is(err, errGroupPending) {
+				t.Errorf("expected errGroupPending, got %v", err)
+			}
+			if tt.wantPhase != "" && status.Phase() != tt.wantPhase {
+				t.Errorf("Phase() = %q, want %q", status.Phase(), tt.wantPhase)
+			}
+			if tt.wantCalls > 0 && calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
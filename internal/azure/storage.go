@@ -0,0 +1,98 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/fileerror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azfile/service"
+	"github.com/cenkalti/backoff/v4"
+)
+
+func (s *AzureFileStorage) client() (*service.Client, error) {
+	cred, err := service.NewSharedKeyCredential(s.AccountName, s.AccountKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage account key: %w", err)
+	}
+	endpoint := s.endpoint
+	if endpoint == "" {
+		endpoint = "https://" + s.AccountName + ".file.core.windows.net/"
+	}
+	client, err := service.NewClientWithSharedKeyCredential(endpoint, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file service client: %w", err)
+	}
+	return client, nil
+}
+
+// ensureFileShares creates the shares a deploy mounts. Shares that already
+// exist keep their data, so a preview's volumes survive redeploys.
+func (d *Deployer) ensureFileShares(ctx context.Context, storage *AzureFileStorage, shares []string) error {
+	if len(shares) == 0 {
+		return nil
+	}
+	client, err := storage.client()
+	if err != nil {
+		return err
+	}
+
+	for _, share := range shares {
+		operation := func() error {
+			_, err := client.CreateShare(ctx, share, nil)
+			if err == nil || fileerror.HasCode(err, fileerror.ShareAlreadyExists) {
+				return nil
+			}
+			if isPermanentError(err) {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+		if err := d.retry(ctx, operation); err != nil {
+			return fmt.Errorf("failed to create file share %s in %s: %w", share, storage.AccountName, err)
+		}
+	}
+	return nil
+}
+
+// DeleteFileShares deletes the shares that back the volumes of the preview
+// in resourceGroup and returns how many it deleted.
+func (d *Deployer) DeleteFileShares(ctx context.Context, storage *AzureFileStorage, resourceGroup string) (int, error) {
+	client, err := storage.client()
+	if err != nil {
+		return 0, err
+	}
+
+	var shares []string
+	pager := client.NewListSharesPager(&service.ListSharesOptions{Prefix: to.Ptr(sharePrefix(resourceGroup))})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list file shares in %s: %w", storage.AccountName, err)
+		}
+		for _, share := range page.Shares {
+			if share.Name != nil {
+				shares = append(shares, *share.Name)
+			}
+		}
+	}
+
+	deleted := 0
+	for _, share := range shares {
+		operation := func() error {
+			_, err := client.DeleteShare(ctx, share, nil)
+			if err == nil || fileerror.HasCode(err, fileerror.ShareNotFound) {
+				return nil
+			}
+			if isPermanentError(err) {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+		if err := d.retry(ctx, operation); err != nil {
+			return deleted, fmt.Errorf("failed to delete file share %s in %s: %w", share, storage.AccountName, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,121 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeFileService serves the Azure Files calls the deployer makes.
+type fakeFileService struct {
+	mu     sync.Mutex
+	shares []string
+}
+
+func (f *fakeFileService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	share := strings.Trim(r.URL.Path, "/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("comp") == "list":
+		var sb strings.Builder
+		sb.WriteString(`<?xml version="1.0" encoding="utf-8"?><EnumerationResults><Shares>`)
+		for _, name := range f.shares {
+			if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+				fmt.Fprintf(&sb, "<Share><Name>%s</Name></Share>", name)
+			}
+		}
+		sb.WriteString(`</Shares><NextMarker /></EnumerationResults>`)
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(sb.String()))
+	case r.Method == http.MethodPut:
+		if slices.Contains(f.shares, share) {
+			w.Header().Set("x-ms-error-code", "ShareAlreadyExists")
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.shares = append(f.shares, share)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodDelete:
+		i := slices.Index(f.shares, share)
+		if i < 0 {
+			w.Header().Set("x-ms-error-code", "ShareNotFound")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.shares = slices.Delete(f.shares, i, i+1)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func newFakeFileStorage(t *testing.T, service *fakeFileService) *AzureFileStorage {
+	t.Helper()
+	server := httptest.NewServer(service)
+	t.Cleanup(server.Close)
+	return &AzureFileStorage{AccountName: "previews", AccountKey: "a2V5", endpoint: server.URL + "/"}
+}
+
+func TestEnsureFileShares(t *testing.T) {
+	existing := ShareName("draftdeploy-acme-app-pr1", "pgdata")
+	service := &fakeFileService{shares: []string{existing}}
+	storage := newFakeFileStorage(t, service)
+	d := newFakeDeployer(t, nil, nil)
+
+	created := ShareName("draftdeploy-acme-app-pr1", "uploads")
+	if err := d.ensureFileShares(context.Background(), storage, []string{existing, created}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(service.shares, []string{existing, created}) {
+		t.Errorf("unexpected shares %v", service.shares)
+	}
+}
+
+func TestDeleteFileShares(t *testing.T) {
+	pr1 := []string{ShareName("draftdeploy-acme-app-pr1", "pgdata"), ShareName("draftdeploy-acme-app-pr1", "uploads")}
+	pr2 := ShareName("draftdeploy-acme-app-pr2", "pgdata")
+	service := &fakeFileService{shares: append(slices.Clone(pr1), pr2)}
+	storage := newFakeFileStorage(t, service)
+	d := newFakeDeployer(t, nil, nil)
+
+	deleted, err := d.DeleteFileShares(context.Background(), storage, "draftdeploy-acme-app-pr1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("expected 2 shares deleted, got %d", deleted)
+	}
+	if !slices.Equal(service.shares, []string{pr2}) {
+		t.Errorf("expected only the other preview's share to remain, got %v", service.shares)
+	}
+}
+
+func TestDeploy_CreatesFileShares(t *testing.T) {
+	service := &fakeFileService{}
+	calls := 0
+	d := newFakeDeployer(t, fakeContainerGroupsServer(&calls, 0, 0, ""), fakeResourceGroupsServer())
+
+	config := DeployConfig{
+		ResourceGroup: "draftdeploy-acme-app-pr1",
+		Name:          "dd-pr1",
+		Location:      "eastus",
+		DNSNameLabel:  "dd-pr1",
+		Storage:       newFakeFileStorage(t, service),
+		Containers: []ContainerConfig{
+			{Name: "db", Image: "postgres", Ports: []int32{5432}, VolumeMounts: []VolumeMount{{Volume: "pgdata", MountPath: "/var/lib/postgresql/data"}}},
+		},
+	}
+	if _, err := d.Deploy(context.Background(), config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{ShareName(config.ResourceGroup, "pgdata")}; !slices.Equal(service.shares, want) {
+		t.Errorf("expected shares %v, got %v", want, service.shares)
+	}
+}
@@ -0,0 +1,139 @@
+package azure
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TagManaged = "draftdeploy"
+	TagRepo    = "repo"
+	TagPR      = "pr"
+	TagBranch  = "branch"
+	TagCreated = "created"
+	TagTTL     = "ttl"
+
+	labelTagPrefix = "draftdeploy-label-"
+	maxLabelTags   = 15
+	maxTagKeyLen   = 512
+	maxTagValueLen = 256
+	// MaxExtraTags leaves room for label and managed tags within Azure's
+	// limit of 50 tags per resource.
+	MaxExtraTags = 20
+)
+
+var invalidTagKeyChars = regexp.MustCompile(`[<>%&\\?/\s]+`)
+
+var reservedTagPrefixes = []string{"microsoft", "azure", "windows"}
+
+// ValidateTag checks a user-supplied tag against Azure's rules for tag
+// names and values.
+func ValidateTag(key, value string) error {
+	switch {
+	case key == "":
+		return fmt.Errorf("tag name is empty")
+	case len(key) > maxTagKeyLen:
+		return fmt.Errorf("tag name %q is longer than %d characters", key, maxTagKeyLen)
+	case strings.ContainsAny(key, `<>%&\?/`):
+		return fmt.Errorf("tag name %q contains one of < > %% & \\ ? /", key)
+	case len(value) > maxTagValueLen:
+		return fmt.Errorf("tag %s has a value longer than %d characters", key, maxTagValueLen)
+	}
+	for _, prefix := range reservedTagPrefixes {
+		if strings.HasPrefix(strings.ToLower(key), prefix) {
+			return fmt.Errorf("tag name %q uses the reserved prefix %q", key, prefix)
+		}
+	}
+	return nil
+}
+
+func LabelTags(labels []string) map[string]string {
+	tags := make(map[string]string)
+	for _, label := range labels {
+		if len(tags) >= maxLabelTags {
+			break
+		}
+
+		name := invalidTagKeyChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(label)), "-")
+		name = strings.Trim(name, "-")
+		if name == "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%s%s", labelTagPrefix, name)
+		if len(key) > maxTagKeyLen {
+			key = key[:maxTagKeyLen]
+		}
+		tags[key] = "true"
+	}
+	return tags
+}
+
+// ManagedTags returns the tags every preview carries. Branch previews pass
+// a prNumber of 0 and get no pr tag.
+func ManagedTags(owner, repo string, prNumber int, created time.Time, ttl time.Duration) map[string]string {
+	tags := map[string]string{
+		TagManaged: "true",
+		TagRepo:    fmt.Sprintf("%s/%s", owner, repo),
+		TagCreated: created.UTC().Format(time.RFC3339),
+		TagTTL:     ttl.String(),
+	}
+	if prNumber > 0 {
+		tags[TagPR] = strconv.Itoa(prNumber)
+	}
+	return tags
+}
+
+func MergeTags(sets ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, set := range sets {
+		for k, v := range set {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+func isExpired(tags map[string]*string, now time.Time) bool {
+	created, ok := tagValue(tags, TagCreated)
+	if !ok {
+		return false
+	}
+	ttl, ok := tagValue(tags, TagTTL)
+	if !ok {
+		return false
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, created)
+	if err != nil {
+		return false
+	}
+	duration, err := time.ParseDuration(ttl)
+	if err != nil {
+		return false
+	}
+	return now.After(createdAt.Add(duration))
+}
+
+// createdTag returns the created tag if it holds a readable time.
+func createdTag(tags map[string]*string) (string, bool) {
+	value, ok := tagValue(tags, TagCreated)
+	if !ok {
+		return "", false
+	}
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+func tagValue(tags map[string]*string, key string) (string, bool) {
+	v, ok := tags[key]
+	if !ok || v == nil {
+		return "", false
+	}
+	return *v, true
+}
@@ -0,0 +1,118 @@
+package azure
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLabelTags(t *testing.T) {
+	tags := LabelTags([]string{"team/payments", "Priority: High", "  ", "bug"})
+
+	expected := map[string]string{
+		"draftdeploy-label-team-payments":  "true",
+		"draftdeploy-label-priority:-high": "true",
+		"draftdeploy-label-bug":            "true",
+	}
+
+	if len(tags) != len(expected) {
+		t.Fatalf("expected %d tags, got %d: %v", len(expected), len(tags), tags)
+	}
+	for k, v := range expected {
+		if tags[k] != v {
+			t.Errorf("expected tag %s=%s, got %q", k, v, tags[k])
+		}
+	}
+}
+
+func TestLabelTags_Capped(t *testing.T) {
+	labels := make([]string, 0, 30)
+	for i := range 30 {
+		labels = append(labels, fmt.Sprintf("label-%d", i))
+	}
+
+	tags := LabelTags(labels)
+	if len(tags) != maxLabelTags {
+		t.Errorf("expected %d tags, got %d", maxLabelTags, len(tags))
+	}
+}
+
+func TestIsExpired(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tags := buildTags(ManagedTags("acme", "shop", 42, created, 24*time.Hour))
+
+	if isExpired(tags, created.Add(23*time.Hour)) {
+		t.Error("expected resource group within TTL to not be expired")
+	}
+	if !isExpired(tags, created.Add(25*time.Hour)) {
+		t.Error("expected resource group past TTL to be expired")
+	}
+}
+
+func TestIsExpired_MissingTags(t *testing.T) {
+	now := time.Now()
+
+	if isExpired(nil, now) {
+		t.Error("expected untagged resource group to never expire")
+	}
+	if isExpired(buildTags(map[string]string{TagCreated: "not-a-time", TagTTL: "1h"}), now) {
+		t.Error("expected malformed created tag to never expire")
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	merged := MergeTags(map[string]string{"a": "1", "b": "1"}, map[string]string{"b": "2"})
+	if merged["a"] != "1" || merged["b"] != "2" {
+		t.Errorf("unexpected merge result: %v", merged)
+	}
+}
+
+func TestManagedTags(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	tags := ManagedTags("acme", "shop", 42, created, time.Hour)
+
+	expected := map[string]string{
+		TagManaged: "true",
+		TagRepo:    "acme/shop",
+		TagPR:      "42",
+		TagCreated: "2024-01-01T11:00:00Z",
+		TagTTL:     "1h0m0s",
+	}
+	for k, v := range expected {
+		if tags[k] != v {
+			t.Errorf("expected tag %s=%s, got %q", k, v, tags[k])
+		}
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{key: "cost-center", value: "1234"},
+		{key: "Owner", value: ""},
+		{key: "", value: "x", wantErr: true},
+		{key: "team/name", value: "x", wantErr: true},
+		{key: "a<b", value: "x", wantErr: true},
+		{key: "Microsoft.Policy", value: "x", wantErr: true},
+		{key: strings.Repeat("k", maxTagKeyLen+1), value: "x", wantErr: true},
+		{key: "note", value: strings.Repeat("v", maxTagValueLen+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateTag(tt.key, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateTag(%.20q, %.20q) error = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestManagedTags_Branch(t *testing.T) {
+	tags := ManagedTags("acme", "shop", 0, time.Now(), time.Hour)
+	if _, ok := tags[TagPR]; ok {
+		t.Errorf("expected no pr tag for a branch preview, got %q", tags[TagPR])
+	}
+}
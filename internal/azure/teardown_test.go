@@ -0,0 +1,138 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2"
+	cifake "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	rgfake "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/fake"
+)
+
+func newFakeDeployer(t *testing.T, containerServer *cifake.ContainerGroupsServer, rgServer *rgfake.ResourceGroupsServer) *Deployer {
+	t.Helper()
+
+	ciFactory := &cifake.ServerFactory{}
+	if containerServer != nil {
+		ciFactory.ContainerGroupsServer = *containerServer
+	}
+	rgFactory := &rgfake.ServerFactory{}
+	if rgServer != nil {
+		rgFactory.ResourceGroupsServer = *rgServer
+	}
+
+	d, err := NewDeployerWithOptions(&azfake.TokenCredential{}, "sub", RetryPolicy{MaxElapsedTime: time.Second, InitialInterval: time.Millisecond}, &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			// Leave retries to the deployer's own policy so tests see every attempt.
+			Retry: policy.RetryOptions{MaxRetries: -1},
+			Transport: &fakeARMTransport{
+				containerInstance: cifake.NewServerFactoryTransport(ciFactory),
+				resources:         rgfake.NewServerFactoryTransport(rgFactory),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create deployer: %v", err)
+	}
+	return d
+}
+
+// fakeARMTransport routes each request to the fake server of the resource
+// provider it targets, so a single set of client options serves every client.
+type fakeARMTransport struct {
+	containerInstance policy.Transporter
+	resources         policy.Transporter
+}
+
+func (f *fakeARMTransport) Do(req *http.Request) (*http.Response, error) {
+	if strings.Contains(strings.ToLower(req.URL.Path), "/providers/microsoft.containerinstance/") {
+		return f.containerInstance.Do(req)
+	}
+	return f.resources.Do(req)
+}
+
+func TestTeardown_ResourceGroupNotFound(t *testing.T) {
+	containerServer := &cifake.ContainerGroupsServer{
+		BeginDelete: func(ctx context.Context, resourceGroupName, containerGroupName string, options *armcontainerinstance.ContainerGroupsClientBeginDeleteOptions) (resp azfake.PollerResponder[armcontainerinstance.ContainerGroupsClientDeleteResponse], errResp azfake.ErrorResponder) {
+			errResp.SetResponseError(http.StatusNotFound, "ResourceGroupNotFound")
+			return
+		},
+	}
+	rgServer := &rgfake.ResourceGroupsServer{
+		BeginDelete: func(ctx context.Context, resourceGroupName string, options *armresources.ResourceGroupsClientBeginDeleteOptions) (resp azfake.PollerResponder[armresources.ResourceGroupsClientDeleteResponse], errResp azfake.ErrorResponder) {
+			errResp.SetResponseError(http.StatusNotFound, "ResourceGroupNotFound")
+			return
+		},
+	}
+
+	existed, err := newFakeDeployer(t, containerServer, rgServer).Teardown(context.Background(), "draftdeploy-rg", "dd-pr1")
+	if err != nil {
+		t.Fatalf("expected teardown of a missing resource group to succeed, got %v", err)
+	}
+	if existed {
+		t.Error("expected resource group to be reported as absent")
+	}
+}
+
+func TestTeardown_DeletesContainerGroupFirst(t *testing.T) {
+	var calls []string
+	containerServer := &cifake.ContainerGroupsServer{
+		BeginDelete: func(ctx context.Context, resourceGroupName, containerGroupName string, options *armcontainerinstance.ContainerGroupsClientBeginDeleteOptions) (resp azfake.PollerResponder[armcontainerinstance.ContainerGroupsClientDeleteResponse], errResp azfake.ErrorResponder) {
+			calls = append(calls, "container-group")
+			resp.SetTerminalResponse(http.StatusOK, armcontainerinstance.ContainerGroupsClientDeleteResponse{}, nil)
+			return
+		},
+	}
+	rgServer := &rgfake.ResourceGroupsServer{
+		BeginDelete: func(ctx context.Context, resourceGroupName string, options *armresources.ResourceGroupsClientBeginDeleteOptions) (resp azfake.PollerResponder[armresources.ResourceGroupsClientDeleteResponse], errResp azfake.ErrorResponder) {
+			calls = append(calls, "resource-group")
+			resp.SetTerminalResponse(http.StatusOK, armresources.ResourceGroupsClientDeleteResponse{}, nil)
+			return
+		},
+	}
+
+	existed, err := newFakeDeployer(t, containerServer, rgServer).Teardown(context.Background(), "draftdeploy-rg", "dd-pr1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !existed {
+		t.Error("expected resource group to be reported as deleted")
+	}
+	if len(calls) != 2 || calls[0] != "container-group" || calls[1] != "resource-group" {
+		t.Errorf("unexpected delete order: %v", calls)
+	}
+}
+
+func TestDeploy_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	containerServer := &cifake.ContainerGroupsServer{
+		BeginCreateOrUpdate: func(context.Context, string, string, armcontainerinstance.ContainerGroup, *armcontainerinstance.ContainerGroupsClientBeginCreateOrUpdateOptions) (resp azfake.PollerResponder[armcontainerinstance.ContainerGroupsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			// The runner cancels the job while the group is being created.
+			cancel()
+			errResp.SetResponseError(http.StatusInternalServerError, "InternalServerError")
+			return
+		},
+	}
+	d := newFakeDeployer(t, containerServer, fakeResourceGroupsServer())
+
+	_, err := d.Deploy(ctx, DeployConfig{
+		ResourceGroup: "draftdeploy-rg",
+		Name:          "dd-pr1",
+		Location:      "eastus",
+		DNSNameLabel:  "dd-pr1",
+		Containers:    []ContainerConfig{{Name: "web", Image: "nginx:alpine", Ports: []int32{80}, CPU: 0.5, MemoryGB: 0.5}},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected deploy to stop with context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package azure
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerinstance/armcontainerinstance/v2"
+)
+
+const (
+	maxVolumeNameLen = 63
+	volumeHashLen    = 6
+	shareHashLen     = 8
+	// shareGroupLen bounds the readable part of a share prefix so the
+	// volume name keeps most of the 63 characters.
+	shareGroupLen = 20
+)
+
+var (
+	invalidVolumeNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+	validVolumeName        = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+type VolumeMount struct {
+	Volume    string
+	MountPath string
+	ReadOnly  bool
+	// Ephemeral mounts (tmpfs and anonymous volumes) are always empty
+	// directories, even when Azure Files storage is configured.
+	Ephemeral bool
+}
+
+type AzureFileStorage struct {
+	AccountName string
+	AccountKey  string
+
+	// endpoint overrides the file service URL in tests.
+	endpoint string
+}
+
+// volumeName turns a compose volume name into a valid Azure name of at most
+// limit characters. Names that had to be changed get a hash of the original
+// so that, for example, my_vol and my-vol stay distinct.
+func volumeName(name string) string {
+	return shortName(name, maxVolumeNameLen)
+}
+
+func shortName(name string, limit int) string {
+	if len(name) <= limit && validVolumeName.MatchString(name) {
+		return name
+	}
+
+	clean := strings.Trim(invalidVolumeNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if n := limit - volumeHashLen - 1; len(clean) > n {
+		clean = strings.TrimRight(clean[:n], "-")
+	}
+	if clean == "" {
+		return hashName(name, volumeHashLen)
+	}
+	return clean + "-" + hashName(name, volumeHashLen)
+}
+
+func hashName(name string, n int) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])[:n]
+}
+
+// sharePrefix starts the name of every file share of a preview, so that
+// previews sharing a storage account never mount each other's data.
+func sharePrefix(resourceGroup string) string {
+	group := strings.Trim(invalidVolumeNameChars.ReplaceAllString(strings.ToLower(resourceGroup), "-"), "-")
+	if len(group) > shareGroupLen {
+		group = strings.TrimRight(group[:shareGroupLen], "-")
+	}
+	return group + "-" + hashName(resourceGroup, shareHashLen) + "-"
+}
+
+// ShareName is the Azure Files share that backs a named volume of the
+// preview in resourceGroup.
+func ShareName(resourceGroup, volume string) string {
+	prefix := sharePrefix(resourceGroup)
+	return prefix + shortName(volume, maxVolumeNameLen-len(prefix))
+}
+
+func buildVolumeMounts(mounts []VolumeMount) []*armcontainerinstance.VolumeMount {
+	if len(mounts) == 0 {
+		return nil
+	}
+
+	result := make([]*armcontainerinstance.VolumeMount, 0, len(mounts))
+	for _, m := range mounts {
+		result = append(result, &armcontainerinstance.VolumeMount{
+			Name:      to.Ptr(volumeName(m.Volume)),
+			MountPath: to.Ptr(m.MountPath),
+			ReadOnly:  to.Ptr(m.ReadOnly),
+		})
+	}
+	return result
+}
+
+func buildVolumes(containers []ContainerConfig, storage *AzureFileStorage, resourceGroup string) []*armcontainerinstance.Volume {
+	var volumes []*armcontainerinstance.Volume
+	seen := make(map[string]bool)
+
+	for _, c := range containers {
+		for _, m := range c.VolumeMounts {
+			name := volumeName(m.Volume)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			volume := &armcontainerinstance.Volume{Name: to.Ptr(name)}
+			if storage != nil && !m.Ephemeral {
+				volume.AzureFile = &armcontainerinstance.AzureFileVolume{
+					ShareName:          to.Ptr(ShareName(resourceGroup, m.Volume)),
+					StorageAccountName: to.Ptr(storage.AccountName),
+					StorageAccountKey:  to.Ptr(storage.AccountKey),
+				}
+			} else {
+				volume.EmptyDir = map[string]any{}
+			}
+			volumes = append(volumes, volume)
+		}
+	}
+	return volumes
+}
+
+// fileShares lists the shares a deploy mounts, one per named volume.
+func fileShares(config DeployConfig) []string {
+	if config.Storage == nil {
+		return nil
+	}
+
+	var shares []string
+	seen := make(map[string]bool)
+	for _, c := range config.Containers {
+		for _, m := range c.VolumeMounts {
+			if m.Ephemeral {
+				continue
+			}
+			share := ShareName(config.ResourceGroup, m.Volume)
+			if !seen[share] {
+				seen[share] = true
+				shares = append(shares, share)
+			}
+		}
+	}
+	return shares
+}
@@ -0,0 +1,118 @@
+package azure
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVolumeName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"pgdata", "pgdata"},
+		{"pg-data", "pg-data"},
+		{"My_Volume", "my-volume-" + hashName("My_Volume", volumeHashLen)},
+		{"--cache--", "cache-" + hashName("--cache--", volumeHashLen)},
+	}
+
+	for _, tt := range tests {
+		if got := volumeName(tt.in); got != tt.want {
+			t.Errorf("volumeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestVolumeName_Distinct(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	pairs := [][2]string{
+		{"my_vol", "my-vol"},
+		{"my.vol", "my_vol"},
+		{long + "x", long + "y"},
+	}
+
+	for _, pair := range pairs {
+		a, b := volumeName(pair[0]), volumeName(pair[1])
+		if a == b {
+			t.Errorf("volumeName(%q) and volumeName(%q) are both %q", pair[0], pair[1], a)
+		}
+		for _, name := range []string{a, b} {
+			if len(name) > maxVolumeNameLen || !validVolumeName.MatchString(name) {
+				t.Errorf("invalid volume name %q", name)
+			}
+		}
+	}
+}
+
+func TestShareName(t *testing.T) {
+	pr1 := ShareName("draftdeploy-acme-app-pr1", "pgdata")
+	pr2 := ShareName("draftdeploy-acme-app-pr2", "pgdata")
+	if pr1 == pr2 {
+		t.Fatalf("expected previews to get different shares, both got %q", pr1)
+	}
+	if !strings.HasPrefix(pr1, sharePrefix("draftdeploy-acme-app-pr1")) || !strings.HasSuffix(pr1, "-pgdata") {
+		t.Errorf("unexpected share name %q", pr1)
+	}
+
+	long := ShareName(strings.Repeat("draftdeploy-", 10), strings.Repeat("volume", 20))
+	if len(long) > maxVolumeNameLen || !validVolumeName.MatchString(long) {
+		t.Errorf("invalid share name %q", long)
+	}
+}
+
+func TestBuildVolumes_EmptyDir(t *testing.T) {
+	containers := []ContainerConfig{
+		{Name: "postgres", VolumeMounts: []VolumeMount{{Volume: "pgdata", MountPath: "/var/lib/postgresql/data"}}},
+		{Name: "backup", VolumeMounts: []VolumeMount{{Volume: "pgdata", MountPath: "/backup", ReadOnly: true}}},
+	}
+
+	volumes := buildVolumes(containers, nil, "draftdeploy-acme-app-pr1")
+	if len(volumes) != 1 {
+		t.Fatalf("expected shared volume to be declared once, got %d", len(volumes))
+	}
+	if volumes[0].EmptyDir == nil || volumes[0].AzureFile != nil {
+		t.Error("expected an EmptyDir volume without storage configured")
+	}
+}
+
+func TestBuildVolumes_AzureFile(t *testing.T) {
+	containers := []ContainerConfig{
+		{Name: "postgres", VolumeMounts: []VolumeMount{
+			{Volume: "pgdata", MountPath: "/var/lib/postgresql/data"},
+			{Volume: "postgres-/tmp", MountPath: "/tmp", Ephemeral: true},
+		}},
+	}
+
+	volumes := buildVolumes(containers, &AzureFileStorage{AccountName: "previews", AccountKey: "key"}, "draftdeploy-acme-app-pr1")
+	if len(volumes) != 2 || volumes[0].AzureFile == nil {
+		t.Fatal("expected an Azure Files volume and an ephemeral volume")
+	}
+	if want := ShareName("draftdeploy-acme-app-pr1", "pgdata"); *volumes[0].AzureFile.ShareName != want || *volumes[0].AzureFile.StorageAccountName != "previews" {
+		t.Errorf("unexpected Azure Files volume: share=%s account=%s, want share %s",
+			*volumes[0].AzureFile.ShareName, *volumes[0].AzureFile.StorageAccountName, want)
+	}
+	if volumes[1].EmptyDir == nil || volumes[1].AzureFile != nil {
+		t.Error("expected the ephemeral volume to stay an EmptyDir")
+	}
+}
+
+func TestFileShares(t *testing.T) {
+	config := DeployConfig{
+		ResourceGroup: "draftdeploy-acme-app-pr1",
+		Storage:       &AzureFileStorage{AccountName: "previews", AccountKey: "key"},
+		Containers: []ContainerConfig{
+			{Name: "postgres", VolumeMounts: []VolumeMount{{Volume: "pgdata"}, {Volume: "postgres-/tmp", Ephemeral: true}}},
+			{Name: "backup", VolumeMounts: []VolumeMount{{Volume: "pgdata", ReadOnly: true}}},
+		},
+	}
+
+	shares := fileShares(config)
+	if len(shares) != 1 || shares[0] != ShareName(config.ResourceGroup, "pgdata") {
+		t.Errorf("unexpected shares %v", shares)
+	}
+
+	config.Storage = nil
+	if shares := fileShares(config); shares != nil {
+		t.Errorf("expected no shares without storage, got %v", shares)
+	}
+}
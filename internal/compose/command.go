@@ -0,0 +1,17 @@
+package compose
+
+func (p *Project) GetServiceCommand(serviceName string) []string {
+	service, ok := p.Services[serviceName]
+	if !ok || len(service.Command) == 0 {
+		return nil
+	}
+	return []string(service.Command)
+}
+
+func (p *Project) GetServiceEntrypoint(serviceName string) []string {
+	service, ok := p.Services[serviceName]
+	if !ok || len(service.Entrypoint) == 0 {
+		return nil
+	}
+	return []string(service.Entrypoint)
+}
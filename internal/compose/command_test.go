@@ -0,0 +1,49 @@
+package compose
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetServiceCommand(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  shell:
+    image: alpine
+    command: "sh -c 'echo hi'"
+  list:
+    image: myapp
+    entrypoint: ["/app/bin"]
+    command: ["server", "--flag"]
+  plain:
+    image: nginx
+`
+
+	project := loadTestCompose(t, yaml)
+
+	tests := []struct {
+		service    string
+		command    []string
+		entrypoint []string
+	}{
+		{"shell", []string{"sh", "-c", "echo hi"}, nil},
+		{"list", []string{"server", "--flag"}, []string{"/app/bin"}},
+		{"plain", nil, nil},
+		{"missing", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			t.Parallel()
+
+			if got := project.GetServiceCommand(tt.service); !reflect.DeepEqual(got, tt.command) {
+				t.Errorf("GetServiceCommand() = %q, want %q", got, tt.command)
+			}
+			if got := project.GetServiceEntrypoint(tt.service); !reflect.DeepEqual(got, tt.entrypoint) {
+				t.Errorf("GetServiceEntrypoint() = %q, want %q", got, tt.entrypoint)
+			}
+		})
+	}
+}
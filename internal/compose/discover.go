@@ -0,0 +1,61 @@
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var defaultFileNames = []string{
+	"compose.yaml",
+	"compose.yml",
+	"docker-compose.yaml",
+	"docker-compose.yml",
+}
+
+var overrideFileNames = []string{
+	"compose.override.yaml",
+	"compose.override.yml",
+	"docker-compose.override.yaml",
+	"docker-compose.override.yml",
+}
+
+func Discover(dir string) ([]string, error) {
+	base, err := findFirst(dir, defaultFileNames)
+	if err != nil {
+		return nil, err
+	}
+	if base == "" {
+		return nil, fmt.Errorf("no compose file found in %s (looked for %v)", dir, defaultFileNames)
+	}
+
+	files := []string{base}
+
+	override, err := findFirst(dir, overrideFileNames)
+	if err != nil {
+		return nil, err
+	}
+	if override != "" {
+		files = append(files, override)
+	}
+
+	return files, nil
+}
+
+func findFirst(dir string, names []string) (string, error) {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,101 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("services: {}\n"), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{"docker-compose only", []string{"docker-compose.yml"}, []string{"docker-compose.yml"}},
+		{"prefers compose.yaml", []string{"docker-compose.yml", "compose.yaml"}, []string{"compose.yaml"}},
+		{"with override", []string{"compose.yaml", "compose.override.yaml"}, []string{"compose.yaml", "compose.override.yaml"}},
+		{"legacy override", []string{"docker-compose.yml", "docker-compose.override.yml"}, []string{"docker-compose.yml", "docker-compose.override.yml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files...)
+
+			got, err := Discover(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i, name := range tt.want {
+				if got[i] != filepath.Join(dir, name) {
+					t.Errorf("expected files[%d] = %s, got %s", i, name, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDiscover_NoFile(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Discover(t.TempDir()); err == nil {
+		t.Error("expected error when no compose file exists")
+	}
+}
+
+func TestLoad_WithOverride(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	base := `
+services:
+  web:
+    image: nginx:alpine
+    ports:
+      - "80:80"
+`
+	override := `
+services:
+  web:
+    image: nginx:latest
+`
+	if err := os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte(base), 0o644); err != nil {
+		t.Fatalf("failed to write base: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "compose.override.yaml"), []byte(override), 0o644); err != nil {
+		t.Fatalf("failed to write override: %v", err)
+	}
+
+	files, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	project, err := Load(files...)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+
+	if img := project.GetServiceImage("web"); img != "nginx:latest" {
+		t.Errorf("expected override image nginx:latest, got %s", img)
+	}
+	if ports := project.GetExposedPorts("web"); len(ports) != 1 {
+		t.Errorf("expected base ports to survive merge, got %v", ports)
+	}
+}
@@ -0,0 +1,73 @@
+package compose
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultEnvDeny keeps CI credentials that compose interpolation may pick
+// up from the runner out of preview containers.
+var DefaultEnvDeny = []string{"*TOKEN*", "*SECRET*", "GITHUB_*"}
+
+// EnvFilter decides which environment variables reach a container. Keys
+// are dropped unless Forward is set. Keys are matched case-insensitively
+// against glob patterns. When Allow is set only matching keys are kept,
+// and keys matching Deny are always dropped.
+type EnvFilter struct {
+	Forward bool
+	Allow   []string
+	Deny    []string
+}
+
+func (f EnvFilter) Validate() error {
+	for _, pattern := range slices.Concat(f.Allow, f.Deny) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid environment pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Apply returns the variables the filter keeps and the sorted keys it
+// dropped. Keys in keep, such as those a service declares with
+// draftdeploy.secrets, were named on purpose and bypass the filter.
+func (f EnvFilter) Apply(env map[string]string, keep []string) (map[string]string, []string) {
+	if env == nil {
+		return nil, nil
+	}
+
+	kept := make(map[string]string, len(env))
+	var dropped []string
+	for k, v := range env {
+		if slices.Contains(keep, k) || f.allows(k) {
+			kept[k] = v
+			continue
+		}
+		dropped = append(dropped, k)
+	}
+	sort.Strings(dropped)
+	return kept, dropped
+}
+
+func (f EnvFilter) allows(key string) bool {
+	if !f.Forward {
+		return false
+	}
+	if len(f.Allow) > 0 && !matchesAny(f.Allow, key) {
+		return false
+	}
+	return !matchesAny(f.Deny, key)
+}
+
+func matchesAny(patterns []string, key string) bool {
+	key = strings.ToUpper(key)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToUpper(pattern), key); ok {
+			return true
+		}
+	}
+	return false
+}
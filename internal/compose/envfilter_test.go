@@ -0,0 +1,94 @@
+package compose
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnvFilter_Apply(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"DATABASE_URL":       "postgres://db/app",
+		"GITHUB_TOKEN":       "ghs_xxx",
+		"GITHUB_SHA":         "abc123",
+		"NPM_TOKEN":          "npm_xxx",
+		"JWT_SECRET":         "dev",
+		"APP_LOG_LEVEL":      "debug",
+		"client_secret_path": "/run/secret",
+	}
+
+	tests := []struct {
+		name        string
+		filter      EnvFilter
+		keep        []string
+		wantKept    []string
+		wantDropped []string
+	}{
+		{
+			name:        "default deny",
+			filter:      EnvFilter{Forward: true, Deny: DefaultEnvDeny},
+			wantKept:    []string{"APP_LOG_LEVEL", "DATABASE_URL"},
+			wantDropped: []string{"GITHUB_SHA", "GITHUB_TOKEN", "JWT_SECRET", "NPM_TOKEN", "client_secret_path"},
+		},
+		{
+			name:        "declared secrets bypass deny",
+			filter:      EnvFilter{Forward: true, Deny: DefaultEnvDeny},
+			keep:        []string{"JWT_SECRET"},
+			wantKept:    []string{"APP_LOG_LEVEL", "DATABASE_URL", "JWT_SECRET"},
+			wantDropped: []string{"GITHUB_SHA", "GITHUB_TOKEN", "NPM_TOKEN", "client_secret_path"},
+		},
+		{
+			name:        "allow prefix",
+			filter:      EnvFilter{Forward: true, Allow: []string{"APP_*", "DATABASE_URL"}, Deny: DefaultEnvDeny},
+			wantKept:    []string{"APP_LOG_LEVEL", "DATABASE_URL"},
+			wantDropped: []string{"GITHUB_SHA", "GITHUB_TOKEN", "JWT_SECRET", "NPM_TOKEN", "client_secret_path"},
+		},
+		{
+			name:        "deny wins over allow",
+			filter:      EnvFilter{Forward: true, Allow: []string{"*"}, Deny: []string{"GITHUB_*"}},
+			wantKept:    []string{"APP_LOG_LEVEL", "DATABASE_URL", "JWT_SECRET", "NPM_TOKEN", "client_secret_path"},
+			wantDropped: []string{"GITHUB_SHA", "GITHUB_TOKEN"},
+		},
+		{
+			name:        "not forwarded keeps only declared secrets",
+			keep:        []string{"JWT_SECRET"},
+			wantKept:    []string{"JWT_SECRET"},
+			wantDropped: []string{"APP_LOG_LEVEL", "DATABASE_URL", "GITHUB_SHA", "GITHUB_TOKEN", "NPM_TOKEN", "client_secret_path"},
+		},
+		{
+			name:     "no patterns",
+			filter:   EnvFilter{Forward: true},
+			wantKept: []string{"APP_LOG_LEVEL", "DATABASE_URL", "GITHUB_SHA", "GITHUB_TOKEN", "JWT_SECRET", "NPM_TOKEN", "client_secret_path"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			kept, dropped := tt.filter.Apply(env, tt.keep)
+			want := make(map[string]string, len(tt.wantKept))
+			for _, k := range tt.wantKept {
+				want[k] = env[k]
+			}
+			if !reflect.DeepEqual(kept, want) {
+				t.Errorf("kept = %v, want %v", kept, want)
+			}
+			if !reflect.DeepEqual(dropped, tt.wantDropped) {
+				t.Errorf("dropped = %v, want %v", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestEnvFilter_Validate(t *testing.T) {
+	t.Parallel()
+
+	if err := (EnvFilter{Allow: []string{"APP_*"}, Deny: DefaultEnvDeny}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (EnvFilter{Deny: []string{"[GITHUB"}}).Validate(); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
@@ -0,0 +1,38 @@
+package compose
+
+import (
+	"strings"
+)
+
+const secretsLabel = "draftdeploy.secrets"
+
+func (p *Project) GetServiceEnvironment(serviceName string) map[string]string {
+	service, ok := p.Services[serviceName]
+	if !ok {
+		return nil
+	}
+
+	env := make(map[string]string, len(service.Environment))
+	for k, v := range service.Environment {
+		if v == nil {
+			continue
+		}
+		env[k] = *v
+	}
+	return env
+}
+
+func (p *Project) GetServiceSecretKeys(serviceName string) []string {
+	service, ok := p.Services[serviceName]
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	for _, key := range strings.Split(service.Labels[secretsLabel], ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,106 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetServiceEnvironment(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  postgres:
+    image: postgres:15
+    environment:
+      POSTGRES_DB: myapp
+      POSTGRES_PASSWORD: pass
+      UNSET_VAR:
+    labels:
+      draftdeploy.secrets: "POSTGRES_PASSWORD, OTHER"
+`
+
+	project := loadTestCompose(t, yaml)
+
+	env := project.GetServiceEnvironment("postgres")
+	expected := map[string]string{"POSTGRES_DB": "myapp", "POSTGRES_PASSWORD": "pass"}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("GetServiceEnvironment() = %v, want %v", env, expected)
+	}
+
+	keys := project.GetServiceSecretKeys("postgres")
+	if !reflect.DeepEqual(keys, []string{"POSTGRES_PASSWORD", "OTHER"}) {
+		t.Errorf("GetServiceSecretKeys() = %v", keys)
+	}
+
+	if project.GetServiceEnvironment("missing") != nil {
+		t.Error("expected nil environment for nonexistent service")
+	}
+}
+
+func TestGetServiceEnvironment_EnvFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"api.env":    "DATABASE_URL=postgres://db/app\nLOG_LEVEL=debug\n",
+		"shared.env": "REGION=eastus\nLOG_LEVEL=info\n",
+		composeFileName: `
+services:
+  api:
+    image: myapp/api
+    env_file:
+      - ./api.env
+      - ./shared.env
+    environment:
+      LOG_LEVEL: warn
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	project, err := Load(filepath.Join(dir, composeFileName))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+
+	env := project.GetServiceEnvironment("api")
+	expected := map[string]string{
+		"DATABASE_URL": "postgres://db/app",
+		"REGION":       "eastus",
+		"LOG_LEVEL":    "warn",
+	}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("GetServiceEnvironment() = %v, want %v", env, expected)
+	}
+}
+
+func TestLoad_MissingEnvFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	yaml := `
+services:
+  api:
+    image: myapp/api
+    env_file: ./missing.env
+`
+	path := filepath.Join(dir, composeFileName)
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected error for missing env file")
+	}
+	if !strings.Contains(err.Error(), "missing.env") {
+		t.Errorf("expected error to name the missing env file, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package compose
+
+import (
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Healthcheck struct {
+	Test        []string
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+}
+
+func (p *Project) GetServiceHealthcheck(serviceName string) *Healthcheck {
+	service, ok := p.Services[serviceName]
+	if !ok || service.HealthCheck == nil || service.HealthCheck.Disable {
+		return nil
+	}
+
+	hc := service.HealthCheck
+	if len(hc.Test) == 0 || hc.Test[0] == "NONE" {
+		return nil
+	}
+
+	result := &Healthcheck{Test: hc.Test}
+	if hc.Interval != nil {
+		result.Interval = time.Duration(*hc.Interval)
+	}
+	if hc.Timeout != nil {
+		result.Timeout = time.Duration(*hc.Timeout)
+	}
+	if hc.StartPeriod != nil {
+		result.StartPeriod = time.Duration(*hc.StartPeriod)
+	}
+	if hc.Retries != nil {
+		result.Retries = int(*hc.Retries)
+	}
+	return result
+}
+
+func (h *Healthcheck) Command() []string {
+	if len(h.Test) < 2 {
+		return nil
+	}
+
+	switch h.Test[0] {
+	case "CMD":
+		return h.Test[1:]
+	case "CMD-SHELL":
+		return []string{"/bin/sh", "-c", strings.Join(h.Test[1:], " ")}
+	default:
+		return nil
+	}
+}
+
+func (h *Healthcheck) HTTPEndpoint() (port int32, urlPath string, ok bool) {
+	args := h.Command()
+	if len(args) == 0 {
+		return 0, "", false
+	}
+	if h.Test[0] == "CMD-SHELL" {
+		args = strings.Fields(args[2])
+	}
+
+	if tool := path.Base(args[0]); tool != "curl" && tool != "wget" {
+		return 0, "", false
+	}
+
+	for _, arg := range args[1:] {
+		u, err := url.Parse(strings.Trim(arg, `"'`))
+		if err != nil || u.Scheme != "http" {
+			continue
+		}
+
+		switch u.Hostname() {
+		case "localhost", "127.0.0.1", "0.0.0.0":
+		default:
+			continue
+		}
+
+		port := 80
+		if p := u.Port(); p != "" {
+			port, err = strconv.Atoi(p)
+			if err != nil || port < 1 || port > 65535 {
+				return 0, "", false
+			}
+		}
+
+		urlPath = u.EscapedPath()
+		if urlPath == "" {
+			urlPath = "/"
+		}
+		return int32(port), urlPath, true
+	}
+	return 0, "", false
+}
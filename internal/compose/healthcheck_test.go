@@ -0,0 +1,93 @@
+package compose
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetServiceHealthcheck(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  web:
+    image: nginx
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost:8080/healthz"]
+      interval: 10s
+      timeout: 3s
+      start_period: 45s
+      retries: 5
+  worker:
+    image: worker
+  disabled:
+    image: worker
+    healthcheck:
+      disable: true
+`
+
+	project := loadTestCompose(t, yaml)
+
+	hc := project.GetServiceHealthcheck("web")
+	if hc == nil {
+		t.Fatal("expected healthcheck for web")
+	}
+	if hc.Interval != 10*time.Second || hc.Timeout != 3*time.Second || hc.Retries != 5 {
+		t.Errorf("unexpected healthcheck timing: %+v", hc)
+	}
+	if hc.StartPeriod != 45*time.Second {
+		t.Errorf("expected start period 45s, got %s", hc.StartPeriod)
+	}
+
+	if project.GetServiceHealthcheck("worker") != nil {
+		t.Error("expected no healthcheck for worker")
+	}
+	if project.GetServiceHealthcheck("disabled") != nil {
+		t.Error("expected no healthcheck for disabled service")
+	}
+}
+
+func TestHealthcheckHTTPEndpoint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		test     []string
+		wantPort int32
+		wantPath string
+		wantOK   bool
+	}{
+		{"curl exec form", []string{"CMD", "curl", "-f", "http://localhost:8080/healthz"}, 8080, "/healthz", true},
+		{"curl shell form", []string{"CMD-SHELL", "curl -f http://localhost/ || exit 1"}, 80, "/", true},
+		{"wget", []string{"CMD", "wget", "-qO-", "http://127.0.0.1:3000/ready"}, 3000, "/ready", true},
+		{"no path", []string{"CMD", "curl", "http://localhost:9000"}, 9000, "/", true},
+		{"remote host", []string{"CMD", "curl", "http://example.com/"}, 0, "", false},
+		{"not http", []string{"CMD", "pg_isready", "-U", "postgres"}, 0, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			hc := &Healthcheck{Test: tt.test}
+			port, path, ok := hc.HTTPEndpoint()
+			if ok != tt.wantOK || port != tt.wantPort || path != tt.wantPath {
+				t.Errorf("HTTPEndpoint() = (%d, %q, %t), want (%d, %q, %t)", port, path, ok, tt.wantPort, tt.wantPath, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestHealthcheckCommand(t *testing.T) {
+	t.Parallel()
+
+	hc := &Healthcheck{Test: []string{"CMD-SHELL", "pg_isready -U postgres"}}
+	cmd := hc.Command()
+	if len(cmd) != 3 || cmd[0] != "/bin/sh" || cmd[2] != "pg_isready -U postgres" {
+		t.Errorf("unexpected shell command: %v", cmd)
+	}
+
+	hc = &Healthcheck{Test: []string{"CMD", "pg_isready"}}
+	if cmd := hc.Command(); len(cmd) != 1 || cmd[0] != "pg_isready" {
+		t.Errorf("unexpected exec command: %v", cmd)
+	}
+}
@@ -0,0 +1,39 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+)
+
+const initLabel = "draftdeploy.init"
+
+// IsInitService reports whether a service is labeled draftdeploy.init=true.
+// Init services run to completion, in startup order, before the other
+// containers of the group start, e.g. to apply database migrations.
+func (p *Project) IsInitService(serviceName string) bool {
+	return strings.EqualFold(strings.TrimSpace(p.GetServiceLabels(serviceName)[initLabel]), "true")
+}
+
+func (p *Project) validateInitService(serviceName string, ingress string) []error {
+	var errs []error
+	if len(p.GetServiceEntrypoint(serviceName)) == 0 && len(p.GetServiceCommand(serviceName)) == 0 {
+		errs = append(errs, fmt.Errorf("service %s: %s=true needs a command or entrypoint", serviceName, initLabel))
+	}
+	if len(p.GetPortMappings(serviceName)) > 0 {
+		errs = append(errs, fmt.Errorf("service %s: %s=true cannot be combined with ports", serviceName, initLabel))
+	}
+	if _, ok := p.Services[serviceName].Labels[pathLabel]; ok {
+		errs = append(errs, fmt.Errorf("service %s: %s=true cannot be combined with %s", serviceName, initLabel, pathLabel))
+	}
+	if serviceName == ingress {
+		errs = append(errs, fmt.Errorf("service %s: %s=true cannot be combined with %s", serviceName, initLabel, ingressLabel))
+	}
+	// Init containers finish before the other containers start, so a
+	// dependency on one of them could never be met.
+	for _, dep := range p.GetServiceDependencies(serviceName) {
+		if !p.IsInitService(dep) && !p.IsServiceExcluded(dep) {
+			errs = append(errs, fmt.Errorf("service %s: %s=true cannot depend on %s, which is neither an init service nor excluded with draftdeploy.deploy=false", serviceName, initLabel, dep))
+		}
+	}
+	return errs
+}
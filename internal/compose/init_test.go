@@ -0,0 +1,171 @@
+package compose
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsInitService(t *testing.T) {
+	t.Parallel()
+
+	project := loadTestCompose(t, `
+services:
+  migrate:
+    image: api
+    command: ["./migrate", "up"]
+    labels:
+      draftdeploy.init: "true"
+  seed:
+    image: api
+    command: ["./seed"]
+    labels:
+      draftdeploy.init: "false"
+  api:
+    image: api
+`)
+
+	tests := []struct {
+		service string
+		want    bool
+	}{
+		{"migrate", true},
+		{"seed", false},
+		{"api", false},
+		{"missing", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			t.Parallel()
+
+			if got := project.IsInitService(tt.service); got != tt.want {
+				t.Errorf("IsInitService(%q) = %t, want %t", tt.service, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_InitServices(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{
+			name: "valid",
+			yaml: `
+services:
+  migrate:
+    image: api
+    entrypoint: ["./migrate"]
+    labels:
+      draftdeploy.init: "true"
+  api:
+    image: api
+    ports:
+      - "3000:3000"
+`,
+		},
+		{
+			name: "no command",
+			yaml: `
+services:
+  migrate:
+    image: api
+    labels:
+      draftdeploy.init: "true"
+  api:
+    image: api
+`,
+			want: []string{"service migrate: draftdeploy.init=true needs a command or entrypoint"},
+		},
+		{
+			name: "ports and ingress",
+			yaml: `
+services:
+  migrate:
+    image: api
+    command: ["./migrate"]
+    ports:
+      - "9000:9000"
+    labels:
+      draftdeploy.init: "true"
+      draftdeploy.ingress: "true"
+  api:
+    image: api
+`,
+			want: []string{
+				"service migrate: draftdeploy.init=true cannot be combined with ports",
+				"service migrate: draftdeploy.init=true cannot be combined with draftdeploy.ingress",
+			},
+		},
+		{
+			name: "depends on a regular service",
+			yaml: `
+services:
+  migrate:
+    image: api
+    command: ["./migrate"]
+    depends_on: [db]
+    labels:
+      draftdeploy.init: "true"
+  db:
+    image: postgres
+`,
+			want: []string{"service migrate: draftdeploy.init=true cannot depend on db"},
+		},
+		{
+			name: "depends on excluded and init services",
+			yaml: `
+services:
+  seed:
+    image: api
+    command: ["./seed"]
+    depends_on: [migrate, db]
+    labels:
+      draftdeploy.init: "true"
+  migrate:
+    image: api
+    command: ["./migrate"]
+    labels:
+      draftdeploy.init: "true"
+  db:
+    image: postgres
+    labels:
+      draftdeploy.deploy: "false"
+  api:
+    image: api
+`,
+		},
+		{
+			name: "only init services",
+			yaml: `
+services:
+  migrate:
+    image: api
+    command: ["./migrate"]
+    labels:
+      draftdeploy.init: "true"
+`,
+			want: []string{"no deployable services"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			errs := loadTestCompose(t, tt.yaml).Validate(nil)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tt.want), len(errs), errs)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("error %d = %q, want it to contain %q", i, errs[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package compose
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+const defaultNetwork = "default"
+
+// GetServiceNetworks returns the sorted compose networks a service joins.
+// Services without a networks section are on the default network, and
+// services with a network_mode return nil.
+func (p *Project) GetServiceNetworks(serviceName string) []string {
+	service, ok := p.Services[serviceName]
+	if !ok || service.NetworkMode != "" {
+		return nil
+	}
+	if len(service.Networks) == 0 {
+		return []string{defaultNetwork}
+	}
+
+	networks := make([]string, 0, len(service.Networks))
+	for name := range service.Networks {
+		networks = append(networks, name)
+	}
+	sort.Strings(networks)
+	return networks
+}
+
+// networkWarnings reports pairs of services that share no network in
+// compose. Containers in a container group share localhost, so that
+// isolation is lost in the preview.
+func (p *Project) networkWarnings() []string {
+	var names []string
+	networks := make(map[string][]string)
+	for _, name := range p.GetServiceNames() {
+		if p.IsServiceExcluded(name) {
+			continue
+		}
+		if n := p.GetServiceNetworks(name); len(n) > 0 {
+			names = append(names, name)
+			networks[name] = n
+		}
+	}
+
+	var warnings []string
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			if !sharesNetwork(networks[a], networks[b]) {
+				warnings = append(warnings, fmt.Sprintf("services %s and %s share no compose network but will reach each other over localhost in the container group", a, b))
+			}
+		}
+	}
+	return warnings
+}
+
+func sharesNetwork(a, b []string) bool {
+	return slices.ContainsFunc(a, func(n string) bool { return slices.Contains(b, n) })
+}
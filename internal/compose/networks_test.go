@@ -0,0 +1,135 @@
+package compose
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetServiceNetworks(t *testing.T) {
+	t.Parallel()
+
+	project := loadTestCompose(t, `
+services:
+  web:
+    image: nginx
+    networks: [frontend, backend]
+  api:
+    image: api
+  tool:
+    image: busybox
+    network_mode: none
+networks:
+  frontend:
+  backend:
+`)
+
+	tests := []struct {
+		service string
+		want    []string
+	}{
+		{"web", []string{"backend", "frontend"}},
+		{"api", []string{"default"}},
+		{"tool", nil},
+		{"missing", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			t.Parallel()
+
+			if got := project.GetServiceNetworks(tt.service); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetServiceNetworks(%q) = %v, want %v", tt.service, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarnings_Networks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{
+			name: "disjoint networks",
+			yaml: `
+services:
+  web:
+    image: nginx
+    networks: [frontend]
+  db:
+    image: postgres
+    networks: [backend]
+networks:
+  frontend:
+  backend:
+`,
+			want: []string{"services db and web share no compose network"},
+		},
+		{
+			name: "bridged through a shared network",
+			yaml: `
+services:
+  web:
+    image: nginx
+    networks: [frontend]
+  api:
+    image: api
+    networks: [frontend, backend]
+  db:
+    image: postgres
+    networks: [backend]
+networks:
+  frontend:
+  backend:
+`,
+			want: []string{"services db and web share no compose network"},
+		},
+		{
+			name: "default network",
+			yaml: `
+services:
+  web:
+    image: nginx
+  db:
+    image: postgres
+`,
+		},
+		{
+			name: "excluded service",
+			yaml: `
+services:
+  web:
+    image: nginx
+    networks: [frontend]
+  db:
+    image: postgres
+    networks: [backend]
+    labels:
+      draftdeploy.deploy: "false"
+networks:
+  frontend:
+  backend:
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			warnings := loadTestCompose(t, tt.yaml).Warnings()
+			if len(warnings) != len(tt.want) {
+				t.Fatalf("expected %d warnings, got %d: %v", len(tt.want), len(warnings), warnings)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(warnings[i], want) {
+					t.Errorf("warning %d = %q, want it to contain %q", i, warnings[i], want)
+				}
+			}
+		})
+	}
+}
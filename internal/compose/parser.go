@@ -4,27 +4,79 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 
+	"github.com/LoriKarikari/draftdeploy/internal/deployerr"
 	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/loader"
 	"github.com/compose-spec/compose-go/v2/types"
 )
 
+const (
+	ingressLabel  = "draftdeploy.ingress"
+	deployLabel   = "draftdeploy.deploy"
+	locationLabel = "draftdeploy.location"
+)
+
 type Project struct {
 	*types.Project
 }
 
-func Load(path string) (*Project, error) {
-	absPath, err := filepath.Abs(path)
+// LoadOptions adjust how compose files are loaded.
+type LoadOptions struct {
+	Profiles []string
+	// ProjectName replaces the name compose derives from the directory, so
+	// ${COMPOSE_PROJECT_NAME} does not depend on the checkout path. It is
+	// normalized the way compose normalizes names.
+	ProjectName string
+}
+
+func Load(paths ...string) (*Project, error) {
+	return LoadWithOptions(LoadOptions{}, paths...)
+}
+
+// LoadWithProfiles loads the compose files with the given profiles active.
+func LoadWithProfiles(profiles []string, paths ...string) (*Project, error) {
+	return LoadWithOptions(LoadOptions{Profiles: profiles}, paths...)
+}
+
+// LoadWithOptions loads the compose files. Every error it returns is a
+// *deployerr.ComposeError.
+func LoadWithOptions(options LoadOptions, paths ...string) (*Project, error) {
+	project, err := load(options, paths)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+		return nil, &deployerr.ComposeError{Err: err}
 	}
+	return project, nil
+}
 
-	opts, err := cli.NewProjectOptions(
-		[]string{absPath},
+func load(options LoadOptions, paths []string) (*Project, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no compose files given")
+	}
+
+	absPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		absPaths = append(absPaths, absPath)
+	}
+	path := strings.Join(paths, ", ")
+
+	optionFns := []cli.ProjectOptionsFn{
 		cli.WithOsEnv,
 		cli.WithDotEnv,
-	)
+		cli.WithProfiles(options.Profiles),
+	}
+	if name := loader.NormalizeProjectName(options.ProjectName); name != "" {
+		optionFns = append(optionFns, cli.WithName(name))
+	}
+
+	opts, err := cli.NewProjectOptions(absPaths, optionFns...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create project options: %w", err)
 	}
@@ -51,28 +103,124 @@ func (p *Project) GetServiceNames() []string {
 }
 
 func (p *Project) GetExposedPorts(serviceName string) []int32 {
+	var ports []int32
+	for _, m := range p.GetPortMappings(serviceName) {
+		if m.IsPublished() {
+			ports = append(ports, m.Target)
+		}
+	}
+	return ports
+}
+
+// GetServiceImage returns the service's image, even when it also has a build
+// section. Services with only a build section return "" and are skipped.
+func (p *Project) GetServiceImage(serviceName string) string {
+	service, ok := p.Services[serviceName]
+	if !ok {
+		return ""
+	}
+	return service.Image
+}
+
+func (p *Project) GetServiceLabels(serviceName string) map[string]string {
 	service, ok := p.Services[serviceName]
 	if !ok {
 		return nil
 	}
+	return service.Labels
+}
 
-	var ports []int32
-	for _, port := range service.Ports {
-		if port.Published == "" {
+func (p *Project) IsServiceExcluded(serviceName string) bool {
+	return strings.EqualFold(strings.TrimSpace(p.GetServiceLabels(serviceName)[deployLabel]), "false")
+}
+
+func (p *Project) GetIngressService() (string, error) {
+	var ingress string
+	for _, name := range p.GetServiceNames() {
+		if p.Services[name].Labels[ingressLabel] != "true" {
 			continue
 		}
-		if port.Target < 1 || port.Target > 65535 {
-			continue
+		if ingress != "" {
+			return "", fmt.Errorf("multiple services labeled %s=true: %s, %s", ingressLabel, ingress, name)
 		}
-		ports = append(ports, int32(port.Target))
+		ingress = name
 	}
-	return ports
+	return ingress, nil
 }
 
-func (p *Project) GetServiceImage(serviceName string) string {
+func (p *Project) GetServiceDependencies(serviceName string) []string {
 	service, ok := p.Services[serviceName]
 	if !ok {
-		return ""
+		return nil
 	}
-	return service.Image
+
+	deps := make([]string, 0, len(service.DependsOn))
+	for dep := range service.DependsOn {
+		deps = append(deps, dep)
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+func (p *Project) GetStartupOrder() ([]string, error) {
+	// Services missing from state have not been visited yet.
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	state := make(map[string]int, len(p.Services))
+	order := make([]string, 0, len(p.Services))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle detected: %s", strings.Join(append(path, name), " -> "))
+		}
+
+		state[name] = visiting
+		path = append(slices.Clone(path), name)
+		for _, dep := range p.GetServiceDependencies(name) {
+			if _, ok := p.Services[dep]; !ok {
+				continue
+			}
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range p.GetServiceNames() {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// GetLocation returns the Azure region requested with draftdeploy.location
+// labels. A preview runs in one region, so every service that sets the
+// label must agree.
+func (p *Project) GetLocation() (string, error) {
+	var location, owner string
+	for _, name := range p.GetServiceNames() {
+		if p.IsServiceExcluded(name) {
+			continue
+		}
+		value := strings.TrimSpace(p.Services[name].Labels[locationLabel])
+		if value == "" {
+			continue
+		}
+		if location != "" && !strings.EqualFold(value, location) {
+			return "", fmt.Errorf("services %s and %s set different %s labels: %s, %s", owner, name, locationLabel, location, value)
+		}
+		location, owner = value, name
+	}
+	return location, nil
 }
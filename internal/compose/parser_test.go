@@ -1,9 +1,14 @@
 package compose
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/LoriKarikari/draftdeploy/internal/deployerr"
+	"github.com/compose-spec/compose-go/v2/types"
 )
 
 const composeFileName = "docker-compose.yml"
@@ -55,6 +60,157 @@ services:
 	}
 }
 
+func TestLoad_ComposeError(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, composeFileName)
+	if err := os.WriteFile(composePath, []byte("services: [web\n"), 0o644); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+	}{
+		{name: "invalid yaml", paths: []string{composePath}},
+		{name: "missing file", paths: []string{filepath.Join(tmpDir, "missing.yml")}},
+		{name: "no files"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Load(tt.paths...)
+			var composeErr *deployerr.ComposeError
+			if !errors.As(err, &composeErr) {
+				t.Fatalf("expected *deployerr.ComposeError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoad_MultipleFiles(t *testing.T) {
+	t.Setenv("DD_TEST_API_TAG", "v2")
+
+	dir := t.TempDir()
+	base := `
+services:
+  api:
+    image: myapp/api:v1
+    depends_on:
+      - postgres
+  postgres:
+    image: postgres:15
+`
+	prod := `
+services:
+  api:
+    image: myapp/api:${DD_TEST_API_TAG}
+  worker:
+    image: myapp/worker:latest
+    depends_on:
+      - api
+`
+	basePath := filepath.Join(dir, "docker-compose.yml")
+	prodPath := filepath.Join(dir, "docker-compose.prod.yml")
+	if err := os.WriteFile(basePath, []byte(base), 0o644); err != nil {
+		t.Fatalf("failed to write base: %v", err)
+	}
+	if err := os.WriteFile(prodPath, []byte(prod), 0o644); err != nil {
+		t.Fatalf("failed to write prod: %v", err)
+	}
+
+	project, err := Load(basePath, prodPath)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+
+	if names := project.GetServiceNames(); !reflect.DeepEqual(names, []string{"api", "postgres", "worker"}) {
+		t.Errorf("GetServiceNames() = %v", names)
+	}
+	if img := project.GetServiceImage("api"); img != "myapp/api:v2" {
+		t.Errorf("expected interpolated override image, got %s", img)
+	}
+
+	order, err := project.GetStartupOrder()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(order, []string{"postgres", "api", "worker"}) {
+		t.Errorf("GetStartupOrder() = %v", order)
+	}
+}
+
+func TestLoadWithProfiles(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  web:
+    image: nginx:alpine
+  mailhog:
+    image: mailhog/mailhog
+    profiles: ["dev"]
+`
+	path := filepath.Join(t.TempDir(), composeFileName)
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		profiles []string
+		want     []string
+	}{
+		{"no profiles", nil, []string{"web"}},
+		{"other profile", []string{"debug"}, []string{"web"}},
+		{"dev profile", []string{"dev"}, []string{"mailhog", "web"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			project, err := LoadWithProfiles(tt.profiles, path)
+			if err != nil {
+				t.Fatalf("failed to load: %v", err)
+			}
+			if names := project.GetServiceNames(); !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("GetServiceNames() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadWithOptions_ProjectName(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  web:
+    image: nginx:alpine
+    environment:
+      PROJECT: ${COMPOSE_PROJECT_NAME}
+`
+	path := filepath.Join(t.TempDir(), composeFileName)
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+
+	project, err := LoadWithOptions(LoadOptions{ProjectName: "Acme-App-pr42"}, path)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if project.Name != "acme-app-pr42" {
+		t.Errorf("Name = %q, want acme-app-pr42", project.Name)
+	}
+	if got := project.GetServiceEnvironment("web")["PROJECT"]; got != "acme-app-pr42" {
+		t.Errorf("${COMPOSE_PROJECT_NAME} resolved to %q, want acme-app-pr42", got)
+	}
+}
+
 func TestGetServiceNames_Sorted(t *testing.T) {
 	t.Parallel()
 
@@ -126,6 +282,9 @@ services:
     image: nginx:alpine
   api:
     build: ./api
+  worker:
+    build: ./worker
+    image: ghcr.io/acme/worker:latest
 `
 
 	project := loadTestCompose(t, yaml)
@@ -134,6 +293,10 @@ services:
 		t.Errorf("expected nginx:alpine, got %s", img)
 	}
 
+	if img := project.GetServiceImage("worker"); img != "ghcr.io/acme/worker:latest" {
+		t.Errorf("expected image of build+image service, got %s", img)
+	}
+
 	if img := project.GetServiceImage("api"); img != "" {
 		t.Errorf("expected empty string for build service, got %s", img)
 	}
@@ -142,3 +305,280 @@ services:
 		t.Errorf("expected empty string for nonexistent service, got %s", img)
 	}
 }
+
+func TestGetLocation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		yaml    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "no label",
+			yaml: `
+services:
+  web:
+    image: nginx
+`,
+		},
+		{
+			name: "agreeing labels",
+			yaml: `
+services:
+  web:
+    image: nginx
+    labels:
+      draftdeploy.location: westus2
+  api:
+    image: api
+    labels:
+      draftdeploy.location: WestUS2
+  db:
+    image: postgres
+`,
+			want: "westus2",
+		},
+		{
+			name: "excluded service ignored",
+			yaml: `
+services:
+  web:
+    image: nginx
+    labels:
+      draftdeploy.location: westus2
+  loadtest:
+    image: k6
+    labels:
+      draftdeploy.location: eastus
+      draftdeploy.deploy: "false"
+`,
+			want: "westus2",
+		},
+		{
+			name: "conflicting labels",
+			yaml: `
+services:
+  web:
+    image: nginx
+    labels:
+      draftdeploy.location: westus2
+  api:
+    image: api
+    labels:
+      draftdeploy.location: eastus
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := loadTestCompose(t, tt.yaml).GetLocation()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetLocation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetLocation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetIngressService(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+  api:
+    image: api
+    ports:
+      - "3000:3000"
+    labels:
+      draftdeploy.ingress: "true"
+`
+
+	project := loadTestCompose(t, yaml)
+	name, err := project.GetIngressService()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "api" {
+		t.Errorf("expected api, got %q", name)
+	}
+}
+
+func TestGetServiceLabels(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  web:
+    image: nginx
+    labels:
+      draftdeploy.ingress: "true"
+      team: frontend
+  api:
+    image: api
+`
+
+	project := loadTestCompose(t, yaml)
+	labels := project.GetServiceLabels("web")
+	if labels["team"] != "frontend" || labels["draftdeploy.ingress"] != "true" {
+		t.Errorf("unexpected labels: %v", labels)
+	}
+	if len(project.GetServiceLabels("api")) != 0 {
+		t.Error("expected no labels for api")
+	}
+	if project.GetServiceLabels("missing") != nil {
+		t.Error("expected nil labels for unknown service")
+	}
+}
+
+func TestIsServiceExcluded(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  web:
+    image: nginx
+  api:
+    image: api
+    labels:
+      draftdeploy.deploy: "true"
+  loadtest:
+    image: k6
+    labels:
+      draftdeploy.deploy: "false"
+  debug:
+    image: busybox
+    labels:
+      draftdeploy.deploy: "False"
+`
+
+	project := loadTestCompose(t, yaml)
+	tests := map[string]bool{"web": false, "api": false, "loadtest": true, "debug": true}
+	for name, want := range tests {
+		if got := project.IsServiceExcluded(name); got != want {
+			t.Errorf("IsServiceExcluded(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestGetIngressService_Unlabeled(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  web:
+    image: nginx
+`
+
+	project := loadTestCompose(t, yaml)
+	name, err := project.GetIngressService()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "" {
+		t.Errorf("expected no ingress service, got %q", name)
+	}
+}
+
+func TestGetIngressService_Multiple(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  web:
+    image: nginx
+    labels:
+      draftdeploy.ingress: "true"
+  api:
+    image: api
+    labels:
+      draftdeploy.ingress: "true"
+`
+
+	project := loadTestCompose(t, yaml)
+	if _, err := project.GetIngressService(); err == nil {
+		t.Error("expected error for multiple ingress services")
+	}
+}
+
+func TestGetStartupOrder(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  api:
+    image: api
+    depends_on:
+      - postgres
+      - cache
+  cache:
+    image: redis
+  frontend:
+    image: nginx
+    depends_on:
+      - api
+  postgres:
+    image: postgres:15
+`
+
+	project := loadTestCompose(t, yaml)
+	order, err := project.GetStartupOrder()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"cache", "postgres", "api", "frontend"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+	for i, name := range expected {
+		if order[i] != name {
+			t.Errorf("expected order[%d] = %s, got %s", i, name, order[i])
+		}
+	}
+}
+
+func TestGetStartupOrder_Cycle(t *testing.T) {
+	t.Parallel()
+
+	project := &Project{Project: &types.Project{
+		Services: types.Services{
+			"a": {Name: "a", Image: "a", DependsOn: types.DependsOnConfig{"b": {}}},
+			"b": {Name: "b", Image: "b", DependsOn: types.DependsOnConfig{"a": {}}},
+		},
+	}}
+
+	if _, err := project.GetStartupOrder(); err == nil {
+		t.Error("expected error for dependency cycle")
+	}
+}
+
+func TestGetStartupOrder_CyclePath(t *testing.T) {
+	t.Parallel()
+
+	project := &Project{Project: &types.Project{
+		Services: types.Services{
+			"a": {Name: "a", Image: "a", DependsOn: types.DependsOnConfig{"b": {}, "c": {}}},
+			"b": {Name: "b", Image: "b", DependsOn: types.DependsOnConfig{"d": {}}},
+			"c": {Name: "c", Image: "c", DependsOn: types.DependsOnConfig{"e": {}}},
+			"d": {Name: "d", Image: "d"},
+			"e": {Name: "e", Image: "e", DependsOn: types.DependsOnConfig{"c": {}}},
+		},
+	}}
+
+	_, err := project.GetStartupOrder()
+	if err == nil || err.Error() != "dependency cycle detected: a -> c -> e -> c" {
+		t.Errorf("expected the cycle through c and e, got %v", err)
+	}
+}
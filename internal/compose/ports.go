@@ -0,0 +1,162 @@
+package compose
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	ProtocolTCP = "tcp"
+	ProtocolUDP = "udp"
+
+	TransportAuto = "auto"
+
+	transportLabel = "draftdeploy.transport"
+	portLabel      = "draftdeploy.port"
+	maxPortRange   = 100
+)
+
+type PortMapping struct {
+	Target    int32
+	Published int32
+	Protocol  string
+}
+
+func (m PortMapping) IsPublished() bool {
+	return m.Published != 0
+}
+
+func (p *Project) GetPortMappings(serviceName string) []PortMapping {
+	service, ok := p.Services[serviceName]
+	if !ok {
+		return nil
+	}
+
+	var mappings []PortMapping
+	for _, port := range service.Ports {
+		if !validPort(int64(port.Target)) {
+			continue
+		}
+		target := int32(port.Target)
+
+		m := PortMapping{Target: target, Protocol: normalizeProtocol(port.Protocol)}
+		if port.Published != "" {
+			m.Published = parsePublished(port.Published, target)
+		}
+		mappings = append(mappings, m)
+	}
+
+	for _, entry := range service.Expose {
+		spec, protocol, _ := strings.Cut(entry, "/")
+		for _, target := range expandPortRange(spec) {
+			mappings = append(mappings, PortMapping{Target: target, Protocol: normalizeProtocol(protocol)})
+		}
+	}
+
+	return mappings
+}
+
+func (p *Project) GetServiceTransport(serviceName string) (string, error) {
+	service, ok := p.Services[serviceName]
+	if !ok {
+		return TransportAuto, nil
+	}
+
+	transport := strings.ToLower(strings.TrimSpace(service.Labels[transportLabel]))
+	switch transport {
+	case "", TransportAuto:
+		return TransportAuto, nil
+	case ProtocolTCP, ProtocolUDP:
+		return transport, nil
+	default:
+		return "", fmt.Errorf("service %s: invalid %s label %q (expected tcp, udp or auto)", serviceName, transportLabel, transport)
+	}
+}
+
+func (p *Project) GetPublishedPorts(serviceName string) ([]PortMapping, error) {
+	transport, err := p.GetServiceTransport(serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var published []PortMapping
+	for _, m := range p.GetPortMappings(serviceName) {
+		if !m.IsPublished() {
+			continue
+		}
+		if transport != TransportAuto {
+			m.Protocol = transport
+		}
+		published = append(published, m)
+	}
+	return published, nil
+}
+
+// GetIngressPort returns the container port named by a draftdeploy.port
+// label, which must be one of the service's published TCP ports. It
+// returns 0 when the label is not set, so callers keep their own choice.
+func (p *Project) GetIngressPort(serviceName string) (int32, error) {
+	value, ok := p.GetServiceLabels(serviceName)[portLabel]
+	if !ok {
+		return 0, nil
+	}
+
+	port, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+	if err != nil || !validPort(port) {
+		return 0, fmt.Errorf("service %s: invalid %s label %q (expected a port number)", serviceName, portLabel, value)
+	}
+	published, err := p.GetPublishedPorts(serviceName)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range published {
+		if m.Target == int32(port) && m.Protocol == ProtocolTCP {
+			return m.Target, nil
+		}
+	}
+	return 0, fmt.Errorf("service %s: %s=%d is not one of its published TCP ports", serviceName, portLabel, port)
+}
+
+func parsePublished(published string, target int32) int32 {
+	low, _, _ := strings.Cut(published, "-")
+	port, err := strconv.ParseInt(low, 10, 32)
+	if err != nil || !validPort(port) {
+		return target
+	}
+	return int32(port)
+}
+
+func expandPortRange(spec string) []int32 {
+	lowStr, highStr, isRange := strings.Cut(strings.TrimSpace(spec), "-")
+	low, err := strconv.ParseInt(lowStr, 10, 32)
+	if err != nil || !validPort(low) {
+		return nil
+	}
+	if !isRange {
+		return []int32{int32(low)}
+	}
+
+	high, err := strconv.ParseInt(highStr, 10, 32)
+	if err != nil || high < low {
+		return nil
+	}
+	high = min(high, 65535, low+maxPortRange-1)
+
+	ports := make([]int32, 0, high-low+1)
+	for port := low; port <= high; port++ {
+		ports = append(ports, int32(port))
+	}
+	return ports
+}
+
+func normalizeProtocol(protocol string) string {
+	if strings.EqualFold(protocol, ProtocolUDP) {
+		return ProtocolUDP
+	}
+	return ProtocolTCP
+}
+
+func validPort(port int64) bool {
+	return port >= 1 && port <= 65535
+}
@@ -0,0 +1,192 @@
+package compose
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetPortMappings(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  web:
+    image: nginx
+    ports:
+      - "8000-8002:8000-8002"
+      - "5000:5000/udp"
+      - "9000"
+    expose:
+      - "3000"
+      - "4000-4001"
+      - "5353/udp"
+`
+
+	project := loadTestCompose(t, yaml)
+	got := project.GetPortMappings("web")
+
+	expected := []PortMapping{
+		{Target: 8000, Published: 8000, Protocol: ProtocolTCP},
+		{Target: 8001, Published: 8001, Protocol: ProtocolTCP},
+		{Target: 8002, Published: 8002, Protocol: ProtocolTCP},
+		{Target: 5000, Published: 5000, Protocol: ProtocolUDP},
+		{Target: 9000, Protocol: ProtocolTCP},
+		{Target: 3000, Protocol: ProtocolTCP},
+		{Target: 4000, Protocol: ProtocolTCP},
+		{Target: 4001, Protocol: ProtocolTCP},
+		{Target: 5353, Protocol: ProtocolUDP},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("GetPortMappings() =\n%+v\nwant\n%+v", got, expected)
+	}
+
+	exposed := project.GetExposedPorts("web")
+	if !reflect.DeepEqual(exposed, []int32{8000, 8001, 8002, 5000}) {
+		t.Errorf("GetExposedPorts() = %v, want only published ports", exposed)
+	}
+}
+
+func TestExpandPortRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec string
+		want int
+	}{
+		{"80", 1},
+		{"8000-8010", 11},
+		{"1-65535", maxPortRange},
+		{"65530-70000", 6},
+		{"9000-8000", 0},
+		{"abc", 0},
+		{"0", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			t.Parallel()
+			if got := expandPortRange(tt.spec); len(got) != tt.want {
+				t.Errorf("expandPortRange(%q) returned %d ports, want %d", tt.spec, len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPublishedPorts_Transport(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  game:
+    image: game
+    ports:
+      - "7777:7777"
+    labels:
+      draftdeploy.transport: udp
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+      - "53:53/udp"
+    expose:
+      - "3000"
+  bad:
+    image: nginx
+    labels:
+      draftdeploy.transport: http3
+`
+
+	project := loadTestCompose(t, yaml)
+
+	game, err := project.GetPublishedPorts("game")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(game) != 1 || game[0].Protocol != ProtocolUDP {
+		t.Errorf("expected label to force UDP, got %+v", game)
+	}
+
+	web, err := project.GetPublishedPorts("web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []PortMapping{
+		{Target: 80, Published: 80, Protocol: ProtocolTCP},
+		{Target: 53, Published: 53, Protocol: ProtocolUDP},
+	}
+	if !reflect.DeepEqual(web, expected) {
+		t.Errorf("GetPublishedPorts(web) = %+v, want %+v", web, expected)
+	}
+
+	if _, err := project.GetPublishedPorts("bad"); err == nil {
+		t.Error("expected error for invalid transport label")
+	}
+}
+
+func TestGetIngressPort(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  annotated:
+    image: app
+    ports:
+      - "9090:9090"
+      - "8080:8080"
+    labels:
+      draftdeploy.port: "8080"
+  unannotated:
+    image: app
+    ports:
+      - "9090:9090"
+      - "8080:8080"
+  unpublished:
+    image: app
+    ports:
+      - "9090:9090"
+    expose:
+      - "8080"
+    labels:
+      draftdeploy.port: "8080"
+  udp:
+    image: app
+    ports:
+      - "8080:8080/udp"
+    labels:
+      draftdeploy.port: "8080"
+  invalid:
+    image: app
+    ports:
+      - "8080:8080"
+    labels:
+      draftdeploy.port: http
+`
+
+	project := loadTestCompose(t, yaml)
+
+	tests := []struct {
+		service string
+		want    int32
+		wantErr bool
+	}{
+		{service: "annotated", want: 8080},
+		{service: "unannotated", want: 0},
+		{service: "unpublished", wantErr: true},
+		{service: "udp", wantErr: true},
+		{service: "invalid", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := project.GetIngressPort(tt.service)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetIngressPort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetIngressPort() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
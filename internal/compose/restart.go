@@ -0,0 +1,37 @@
+package compose
+
+import "strings"
+
+const (
+	RestartNo            = "no"
+	RestartAlways        = "always"
+	RestartOnFailure     = "on-failure"
+	RestartUnlessStopped = "unless-stopped"
+)
+
+// GetServiceRestart returns the service's restart policy without a retry
+// count ("on-failure:3" becomes "on-failure"). deploy.restart_policy is used
+// when restart is not set. It returns "" when neither is set.
+func (p *Project) GetServiceRestart(serviceName string) string {
+	service, ok := p.Services[serviceName]
+	if !ok {
+		return ""
+	}
+
+	if restart := strings.TrimSpace(service.Restart); restart != "" {
+		policy, _, _ := strings.Cut(strings.ToLower(restart), ":")
+		return policy
+	}
+
+	if service.Deploy != nil && service.Deploy.RestartPolicy != nil {
+		switch strings.ToLower(service.Deploy.RestartPolicy.Condition) {
+		case "none":
+			return RestartNo
+		case "on-failure":
+			return RestartOnFailure
+		case "any":
+			return RestartAlways
+		}
+	}
+	return ""
+}
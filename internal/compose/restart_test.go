@@ -0,0 +1,60 @@
+package compose
+
+import "testing"
+
+func TestGetServiceRestart(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  web:
+    image: nginx
+  api:
+    image: api
+    restart: always
+  migrate:
+    image: api
+    restart: "no"
+  seed:
+    image: api
+    restart: on-failure:3
+  worker:
+    image: api
+    restart: unless-stopped
+  job:
+    image: api
+    deploy:
+      restart_policy:
+        condition: none
+  retry:
+    image: api
+    deploy:
+      restart_policy:
+        condition: on-failure
+`
+
+	project := loadTestCompose(t, yaml)
+
+	tests := []struct {
+		service string
+		want    string
+	}{
+		{"web", ""},
+		{"api", RestartAlways},
+		{"migrate", RestartNo},
+		{"seed", RestartOnFailure},
+		{"worker", RestartUnlessStopped},
+		{"job", RestartNo},
+		{"retry", RestartOnFailure},
+		{"missing", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			t.Parallel()
+			if got := project.GetServiceRestart(tt.service); got != tt.want {
+				t.Errorf("GetServiceRestart(%q) = %q, want %q", tt.service, got, tt.want)
+			}
+		})
+	}
+}
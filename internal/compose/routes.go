@@ -0,0 +1,69 @@
+package compose
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const pathLabel = "draftdeploy.path"
+
+var routePathPattern = regexp.MustCompile(`^/[A-Za-z0-9._~/-]*$`)
+
+// Route sends requests under Path to Service.
+type Route struct {
+	Service string
+	Path    string
+}
+
+// GetRoutes returns the routes declared with draftdeploy.path labels, in
+// service name order. Excluded services are ignored.
+func (p *Project) GetRoutes() ([]Route, error) {
+	var routes []Route
+	owners := make(map[string]string)
+	for _, name := range p.GetServiceNames() {
+		if p.IsServiceExcluded(name) {
+			continue
+		}
+		value, ok := p.Services[name].Labels[pathLabel]
+		if !ok {
+			continue
+		}
+
+		path := strings.TrimSpace(value)
+		if len(path) > 1 {
+			path = strings.TrimRight(path, "/")
+		}
+		if !routePathPattern.MatchString(path) {
+			return nil, fmt.Errorf("service %s: invalid %s %q, must be a URL path starting with /", name, pathLabel, value)
+		}
+		if owner, taken := owners[path]; taken {
+			return nil, fmt.Errorf("services %s and %s both use %s=%s", owner, name, pathLabel, path)
+		}
+		owners[path] = name
+		routes = append(routes, Route{Service: name, Path: path})
+	}
+	return routes, nil
+}
+
+// routerPort is where the path router listens when routes are declared.
+const routerPort = 80
+
+func (p *Project) validateRoutes(routes []Route, portOwners map[string]string) []error {
+	var errs []error
+	if owner, taken := portOwners[fmt.Sprintf("%d/%s", routerPort, ProtocolTCP)]; taken {
+		errs = append(errs, fmt.Errorf("service %s: port %d is used by the router for %s labels, move the service to another port", owner, routerPort, pathLabel))
+	}
+	for _, route := range routes {
+		published, err := p.GetPublishedPorts(route.Service)
+		if err != nil {
+			continue
+		}
+		hasTCP := slices.ContainsFunc(published, func(m PortMapping) bool { return m.Protocol == ProtocolTCP })
+		if !hasTCP {
+			errs = append(errs, fmt.Errorf("service %s: %s needs a TCP port to route to", route.Service, pathLabel))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,92 @@
+package compose
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGetRoutes(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  frontend:
+    image: web
+    labels:
+      draftdeploy.path: /
+  api:
+    image: api
+    labels:
+      draftdeploy.path: /api/
+  db:
+    image: postgres
+  docs:
+    image: docs
+    labels:
+      draftdeploy.path: /docs
+      draftdeploy.deploy: "false"
+`
+
+	routes, err := loadTestCompose(t, yaml).GetRoutes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Route{{Service: "api", Path: "/api"}, {Service: "frontend", Path: "/"}}
+	if !slices.Equal(routes, want) {
+		t.Errorf("GetRoutes() = %v, want %v", routes, want)
+	}
+}
+
+func TestGetRoutes_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{
+			name: "relative path",
+			yaml: `
+services:
+  api:
+    image: api
+    labels:
+      draftdeploy.path: api
+`,
+		},
+		{
+			name: "unsafe characters",
+			yaml: `
+services:
+  api:
+    image: api
+    labels:
+      draftdeploy.path: "/api; return 200"
+`,
+		},
+		{
+			name: "duplicate path",
+			yaml: `
+services:
+  api:
+    image: api
+    labels:
+      draftdeploy.path: /api
+  legacy:
+    image: legacy
+    labels:
+      draftdeploy.path: /api/
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := loadTestCompose(t, tt.yaml).GetRoutes(); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// Validate reports every problem that would stop the project from deploying,
+// so they can all be fixed at once. Services with a build section need an
+// entry in imageOverrides.
+func (p *Project) Validate(imageOverrides map[string]string) []error {
+	var errs []error
+	ingress, err := p.GetIngressService()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := p.GetLocation(); err != nil {
+		errs = append(errs, err)
+	}
+	routes, err := p.GetRoutes()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if ingress != "" && len(routes) > 0 {
+		errs = append(errs, fmt.Errorf("%s=true on %s cannot be combined with %s labels", ingressLabel, ingress, pathLabel))
+	}
+
+	deployable := 0
+	portOwners := make(map[string]string)
+	for _, name := range p.GetServiceNames() {
+		if p.IsServiceExcluded(name) {
+			continue
+		}
+
+		service := p.Services[name]
+		if service.Image == "" && imageOverrides[name] == "" {
+			if service.Build != nil {
+				errs = append(errs, fmt.Errorf("service %s: has a build section but no image, set an image or add it to DD_IMAGE_OVERRIDES", name))
+			} else {
+				errs = append(errs, fmt.Errorf("service %s: no image", name))
+			}
+			continue
+		}
+		if p.IsInitService(name) {
+			errs = append(errs, p.validateInitService(name, ingress)...)
+		} else {
+			deployable++
+		}
+		errs = append(errs, p.validatePorts(name, portOwners)...)
+
+		keys := make([]string, 0, len(service.Environment))
+		for key := range service.Environment {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			if !envKeyPattern.MatchString(key) {
+				errs = append(errs, fmt.Errorf("service %s: invalid environment variable name %q", name, key))
+			}
+		}
+	}
+
+	if len(routes) > 0 {
+		errs = append(errs, p.validateRoutes(routes, portOwners)...)
+	}
+	if deployable == 0 {
+		errs = append(errs, errors.New("no deployable services"))
+	}
+	return errs
+}
+
+// Warnings reports compose features that deploy but behave differently in
+// a container group. Unlike Validate's problems they do not stop a deploy.
+func (p *Project) Warnings() []string {
+	return append(p.networkWarnings(), p.bindMountWarnings()...)
+}
+
+// Containers in a container group share one network namespace, so a port
+// can only be published by one service.
+func (p *Project) validatePorts(serviceName string, portOwners map[string]string) []error {
+	var errs []error
+	service := p.Services[serviceName]
+	for _, port := range service.Ports {
+		if !validPort(int64(port.Target)) {
+			errs = append(errs, fmt.Errorf("service %s: invalid container port %d", serviceName, port.Target))
+			continue
+		}
+		if port.Published == "" {
+			continue
+		}
+		low, _, _ := strings.Cut(port.Published, "-")
+		if n, err := strconv.ParseInt(low, 10, 32); err != nil || !validPort(n) {
+			errs = append(errs, fmt.Errorf("service %s: invalid published port %q", serviceName, port.Published))
+		}
+	}
+	for _, entry := range service.Expose {
+		spec, _, _ := strings.Cut(entry, "/")
+		if len(expandPortRange(spec)) == 0 {
+			errs = append(errs, fmt.Errorf("service %s: invalid expose entry %q", serviceName, entry))
+		}
+	}
+
+	published, err := p.GetPublishedPorts(serviceName)
+	if err != nil {
+		return append(errs, err)
+	}
+	for _, m := range published {
+		key := fmt.Sprintf("%d/%s", m.Target, m.Protocol)
+		owner, taken := portOwners[key]
+		switch {
+		case !taken:
+			portOwners[key] = serviceName
+		case owner != serviceName:
+			errs = append(errs, fmt.Errorf("services %s and %s both use port %s, but containers in a preview share one network", owner, serviceName, key))
+		}
+	}
+	if _, err := p.GetIngressPort(serviceName); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
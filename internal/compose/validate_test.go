@@ -0,0 +1,176 @@
+package compose
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		yaml      string
+		overrides map[string]string
+		want      []string
+	}{
+		{
+			name: "valid",
+			yaml: `
+services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+    environment:
+      LOG_LEVEL: debug
+  api:
+    image: api
+    ports:
+      - "3000:3000"
+      - "5000:5000/udp"
+`,
+		},
+		{
+			name: "build without override",
+			yaml: `
+services:
+  web:
+    image: nginx
+  app:
+    build: .
+`,
+			want: []string{"service app: has a build section but no image"},
+		},
+		{
+			name: "build with override",
+			yaml: `
+services:
+  app:
+    build: .
+`,
+			overrides: map[string]string{"app": "ghcr.io/acme/app:latest"},
+		},
+		{
+			name: "nothing deployable",
+			yaml: `
+services:
+  app:
+    build: .
+  loadtest:
+    image: k6
+    labels:
+      draftdeploy.deploy: "false"
+`,
+			want: []string{"service app: has a build section", "no deployable services"},
+		},
+		{
+			name: "duplicate ports",
+			yaml: `
+services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+  admin:
+    image: nginx
+    ports:
+      - "8081:80"
+  dns:
+    image: coredns
+    ports:
+      - "53:53/udp"
+      - "53:53/tcp"
+`,
+			want: []string{"services admin and web both use port 80/tcp"},
+		},
+		{
+			name: "invalid ports",
+			yaml: `
+services:
+  web:
+    image: nginx
+    ports:
+      - target: 70000
+        published: "8080"
+      - target: 80
+        published: "http"
+`,
+			want: []string{"service web: invalid container port 70000", `service web: invalid published port "http"`},
+		},
+		{
+			name: "invalid expose",
+			yaml: `
+services:
+  web:
+    image: nginx
+    expose:
+      - "http"
+`,
+			want: []string{`service web: invalid expose entry "http"`},
+		},
+		{
+			name: "invalid environment name",
+			yaml: `
+services:
+  web:
+    image: nginx
+    environment:
+      "1BAD": x
+      "HAS SPACE": y
+      GOOD_NAME: z
+`,
+			want: []string{`service web: invalid environment variable name "1BAD"`, `service web: invalid environment variable name "HAS SPACE"`},
+		},
+		{
+			name: "invalid labels",
+			yaml: `
+services:
+  web:
+    image: nginx
+    labels:
+      draftdeploy.ingress: "true"
+      draftdeploy.transport: sctp
+  api:
+    image: api
+    labels:
+      draftdeploy.ingress: "true"
+`,
+			want: []string{"multiple services labeled draftdeploy.ingress=true", `invalid draftdeploy.transport label "sctp"`},
+		},
+		{
+			name: "path routes",
+			yaml: `
+services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+    labels:
+      draftdeploy.path: /
+  api:
+    image: api
+    labels:
+      draftdeploy.path: /api
+      draftdeploy.ingress: "true"
+`,
+			want: []string{"draftdeploy.ingress=true on api cannot be combined with draftdeploy.path labels", "service web: port 80 is used by the router", "service api: draftdeploy.path needs a TCP port"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			errs := loadTestCompose(t, tt.yaml).Validate(tt.overrides)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tt.want), len(errs), errs)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("error %d = %q, want it to contain %q", i, errs[i], want)
+				}
+			}
+		})
+	}
+}
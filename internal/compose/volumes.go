@@ -0,0 +1,64 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+const (
+	VolumeTypeBind   = types.VolumeTypeBind
+	VolumeTypeVolume = types.VolumeTypeVolume
+	VolumeTypeTmpfs  = types.VolumeTypeTmpfs
+)
+
+type Volume struct {
+	Type     string
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+func (v Volume) IsBind() bool {
+	return v.Type == VolumeTypeBind
+}
+
+func (p *Project) GetServiceVolumes(serviceName string) []Volume {
+	service, ok := p.Services[serviceName]
+	if !ok {
+		return nil
+	}
+
+	volumes := make([]Volume, 0, len(service.Volumes))
+	for _, v := range service.Volumes {
+		volumes = append(volumes, Volume{
+			Type:     v.Type,
+			Source:   v.Source,
+			Target:   v.Target,
+			ReadOnly: v.ReadOnly,
+		})
+	}
+	return volumes
+}
+
+// bindMountWarnings reports services with bind mounts. Host paths do not
+// exist in Azure, so those mounts are left out of the preview.
+func (p *Project) bindMountWarnings() []string {
+	var warnings []string
+	for _, name := range p.GetServiceNames() {
+		if p.IsServiceExcluded(name) {
+			continue
+		}
+		var mounts []string
+		for _, v := range p.GetServiceVolumes(name) {
+			if v.IsBind() {
+				mounts = append(mounts, v.Source+":"+v.Target)
+			}
+		}
+		if len(mounts) > 0 {
+			warnings = append(warnings, fmt.Sprintf("service %s: bind mounts %s will not be present in the preview because host paths are not available in Azure", name, strings.Join(mounts, ", ")))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,84 @@
+package compose
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetServiceVolumes(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  postgres:
+    image: postgres:15
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+      - ./init.sql:/docker-entrypoint-initdb.d/init.sql:ro
+      - type: tmpfs
+        target: /tmp
+volumes:
+  pgdata:
+`
+
+	project := loadTestCompose(t, yaml)
+	volumes := project.GetServiceVolumes("postgres")
+	if len(volumes) != 3 {
+		t.Fatalf("expected 3 volumes, got %d", len(volumes))
+	}
+
+	if volumes[0].Type != VolumeTypeVolume || volumes[0].Source != "pgdata" || volumes[0].Target != "/var/lib/postgresql/data" {
+		t.Errorf("unexpected named volume: %+v", volumes[0])
+	}
+	if !volumes[1].IsBind() || !volumes[1].ReadOnly {
+		t.Errorf("expected read-only bind mount, got %+v", volumes[1])
+	}
+	if volumes[2].Type != VolumeTypeTmpfs || volumes[2].Target != "/tmp" {
+		t.Errorf("unexpected tmpfs volume: %+v", volumes[2])
+	}
+
+	if project.GetServiceVolumes("missing") != nil {
+		t.Error("expected nil volumes for nonexistent service")
+	}
+}
+
+func TestWarnings_BindMounts(t *testing.T) {
+	t.Parallel()
+
+	yaml := `
+services:
+  web:
+    image: nginx
+    volumes:
+      - ./src:/app
+      - ./conf/nginx.conf:/etc/nginx/nginx.conf:ro
+      - cache:/var/cache
+  db:
+    image: postgres
+    volumes:
+      - data:/var/lib/postgresql/data
+  tools:
+    image: busybox
+    volumes:
+      - ./scripts:/scripts
+    labels:
+      draftdeploy.deploy: "false"
+volumes:
+  cache:
+  data:
+`
+	warnings := loadTestCompose(t, yaml).Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d: %v", len(warnings), warnings)
+	}
+	for _, want := range []string{"service web", "/src:/app", "/conf/nginx.conf:/etc/nginx/nginx.conf"} {
+		if !strings.Contains(warnings[0], want) {
+			t.Errorf("warning %q does not contain %q", warnings[0], want)
+		}
+	}
+	for _, unwanted := range []string{"cache", "data", "scripts"} {
+		if strings.Contains(warnings[0], unwanted) {
+			t.Errorf("warning %q should not mention %q", warnings[0], unwanted)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const FileName = ".draftdeploy.yml"
+
+type Config struct {
+	Location       string            `yaml:"location"`
+	CPU            float64           `yaml:"cpu"`
+	MemoryGB       float64           `yaml:"memory_gb"`
+	IngressService string            `yaml:"ingress_service"`
+	ImageOverrides map[string]string `yaml:"image_overrides"`
+}
+
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+func (c *Config) Validate() error {
+	if c.CPU < 0 {
+		return fmt.Errorf("cpu must not be negative, got %v", c.CPU)
+	}
+	if c.MemoryGB < 0 {
+		return fmt.Errorf("memory_gb must not be negative, got %v", c.MemoryGB)
+	}
+	for service, image := range c.ImageOverrides {
+		if service == "" || image == "" {
+			return fmt.Errorf("image_overrides entries need both a service and an image")
+		}
+	}
+	return nil
+}
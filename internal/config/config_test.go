@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfig(t, `
+location: westeurope
+cpu: 1
+memory_gb: 1.5
+ingress_service: frontend
+image_overrides:
+  api: ghcr.io/acme/api:pr-1
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Location != "westeurope" {
+		t.Errorf("expected location westeurope, got %s", cfg.Location)
+	}
+	if cfg.CPU != 1 || cfg.MemoryGB != 1.5 {
+		t.Errorf("expected cpu 1 and memory 1.5, got %v/%v", cfg.CPU, cfg.MemoryGB)
+	}
+	if cfg.IngressService != "frontend" {
+		t.Errorf("expected ingress service frontend, got %s", cfg.IngressService)
+	}
+	if cfg.ImageOverrides["api"] != "ghcr.io/acme/api:pr-1" {
+		t.Errorf("expected api image override, got %v", cfg.ImageOverrides)
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("expected missing file to be a no-op, got %v", err)
+	}
+	if cfg == nil {
+		t.Fatal("expected empty config")
+	}
+}
+
+func TestLoad_Empty(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Load(writeConfig(t, "")); err != nil {
+		t.Fatalf("expected empty file to load, got %v", err)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown field", "regoin: eastus\n"},
+		{"negative cpu", "cpu: -1\n"},
+		{"negative memory", "memory_gb: -0.5\n"},
+		{"empty override", "image_overrides:\n  api: \"\"\n"},
+		{"malformed", "cpu: [\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := Load(writeConfig(t, tt.content)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package deployerr
+
+import (
+	"fmt"
+	"time"
+)
+
+// ComposeError reports a compose project that could not be loaded or that
+// cannot be deployed as written.
+type ComposeError struct {
+	Err error
+}
+
+func (e *ComposeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ComposeError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigError reports an invalid setting in the environment, the command
+// line or .draftdeploy.yml.
+type ConfigError struct {
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// AuthError reports credentials that are missing or that the provider
+// rejected. Provider is "Azure" or "GitHub".
+type AuthError struct {
+	Provider string
+	Err      error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("%s authentication failed: %v", e.Provider, e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// QuotaError reports a deploy that Azure rejected because a subscription
+// quota is used up. Retrying does not help until previews are deleted or
+// the quota is raised.
+type QuotaError struct {
+	Resource string
+	Location string
+	Code     string
+	Err      error
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("Azure quota exceeded for %s in %s (%s); request an increase or reduce concurrent previews", e.Resource, e.Location, e.Code)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutError reports an operation that did not finish before its
+// deadline. Timeout is zero when the deadline came from the caller.
+type TimeoutError struct {
+	Operation string
+	Timeout   time.Duration
+	Err       error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("%s timed out after %s: %v", e.Operation, e.Timeout, e.Err)
+	}
+	return fmt.Sprintf("%s timed out: %v", e.Operation, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,38 @@
+package deployerr
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErrorMessages(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("cause")
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "compose", err: &ComposeError{Err: cause}, want: "cause"},
+		{name: "config", err: &ConfigError{Err: cause}, want: "cause"},
+		{name: "auth", err: &AuthError{Provider: "Azure", Err: cause}, want: "Azure authentication failed: cause"},
+		{name: "quota", err: &QuotaError{Resource: "cores", Location: "eastus", Code: "QuotaExceeded", Err: cause}, want: "Azure quota exceeded for cores in eastus (QuotaExceeded); request an increase or reduce concurrent previews"},
+		{name: "timeout", err: &TimeoutError{Operation: "deploy", Err: cause}, want: "deploy timed out: cause"},
+		{name: "timeout with duration", err: &TimeoutError{Operation: "waiting for the deploy lock", Timeout: 5 * time.Minute, Err: cause}, want: "waiting for the deploy lock timed out after 5m0s: cause"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+			if !errors.Is(tt.err, cause) {
+				t.Error("expected error to unwrap to its cause")
+			}
+		})
+	}
+}
@@ -3,7 +3,9 @@ package github
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/google/go-github/v57/github"
@@ -14,23 +16,60 @@ type Commenter struct {
 	tokenSource oauth2.TokenSource
 	owner       string
 	repo        string
+	template    *template.Template
 }
 
 type DeploymentInfo struct {
-	FQDN       string
-	Services   []ServiceInfo
-	DeployTime time.Duration
+	FQDN              string
+	CustomDomain      string
+	ContainerGroup    string
+	ProvisioningState string
+	Services          []ServiceInfo
+	DeployTime        time.Duration
+	// ResourceGroupTime and ContainerGroupTime break down DeployTime when
+	// both are set.
+	ResourceGroupTime  time.Duration
+	ContainerGroupTime time.Duration
+	LogsURL            string
+	CostPerDay         float64
+	Readiness          string
+	SHA                string
+}
+
+type ContainerLog struct {
+	Container string
+	Output    string
 }
 
 type ServiceInfo struct {
-	Name  string
-	Ports []int32
+	Name     string
+	Ports    []int32
+	UDPPorts []int32
+	Public   bool
+	// IngressPort is the port named by the draftdeploy.port label, or 0.
+	IngressPort int32
+	// IngressOnly is set when IngressPort is the only port published.
+	IngressOnly bool
+	// Path is set when the service is reached through the path router.
+	Path string
 }
 
-const commentMarker = "<!-- draftdeploy -->"
+const (
+	commentMarker      = "<!-- draftdeploy -->"
+	maxErrorSummaryLen = 500
+	maxContainerLogLen = 4000
+	commentsPerPage    = 100
+
+	ReadinessReady        = "ready"
+	ReadinessProvisioning = "provisioning"
+	ReadinessFailed       = "failed"
+)
 
 func NewCommenter(token, owner, repo string) *Commenter {
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	return NewCommenterWithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), owner, repo)
+}
+
+func NewCommenterWithTokenSource(ts oauth2.TokenSource, owner, repo string) *Commenter {
 	return &Commenter{
 		tokenSource: ts,
 		owner:       owner,
@@ -44,15 +83,69 @@ func (c *Commenter) getClient(ctx context.Context) *github.Client {
 }
 
 func (c *Commenter) PostDeployment(ctx context.Context, prNumber int, info DeploymentInfo) error {
-	body := formatDeploymentComment(info)
+	body, err := c.deploymentComment(info)
+	if err != nil {
+		return err
+	}
 	return c.postComment(ctx, prNumber, body)
 }
 
+func (c *Commenter) PostCommitDeployment(ctx context.Context, sha string, info DeploymentInfo) error {
+	body, err := c.deploymentComment(info)
+	if err != nil {
+		return err
+	}
+	client := c.getClient(ctx)
+
+	err = withRateLimitRetry(ctx, func() error {
+		_, _, err := client.Repositories.CreateComment(ctx, c.owner, c.repo, sha, &github.RepositoryComment{
+			Body: github.String(body),
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create commit comment: %w", err)
+	}
+	return nil
+}
+
 func (c *Commenter) PostTeardown(ctx context.Context, prNumber int, info DeploymentInfo) error {
-	body := formatTeardownComment(info)
+	body, err := c.teardownComment(info)
+	if err != nil {
+		return err
+	}
+	return c.postComment(ctx, prNumber, body)
+}
+
+func (c *Commenter) PostMerged(ctx context.Context, prNumber int, info DeploymentInfo, expires time.Time) error {
+	body := formatMergedComment(info, expires)
+	return c.postComment(ctx, prNumber, body)
+}
+
+func (c *Commenter) PostSkipped(ctx context.Context, prNumber int, reason string) error {
+	body := formatSkippedComment(reason)
 	return c.postComment(ctx, prNumber, body)
 }
 
+func (c *Commenter) PostFailure(ctx context.Context, prNumber int, errSummary, hint, logsURL string, logs []ContainerLog) error {
+	body := formatFailureComment(errSummary, hint, logsURL, logs)
+	return c.postComment(ctx, prNumber, body)
+}
+
+func (c *Commenter) deploymentComment(info DeploymentInfo) (string, error) {
+	if c.template == nil {
+		return formatDeploymentComment(info), nil
+	}
+	return renderComment(c.template, newCommentData(info, CommentStatusDeployed))
+}
+
+func (c *Commenter) teardownComment(info DeploymentInfo) (string, error) {
+	if c.template == nil {
+		return formatTeardownComment(info), nil
+	}
+	return renderComment(c.template, newCommentData(info, CommentStatusTornDown))
+}
+
 func (c *Commenter) postComment(ctx context.Context, prNumber int, body string) error {
 	client := c.getClient(ctx)
 
@@ -62,8 +155,11 @@ func (c *Commenter) postComment(ctx context.Context, prNumber int, body string)
 	}
 
 	if existingID != 0 {
-		_, _, err = client.Issues.EditComment(ctx, c.owner, c.repo, existingID, &github.IssueComment{
-			Body: github.String(body),
+		err = withRateLimitRetry(ctx, func() error {
+			_, _, err := client.Issues.EditComment(ctx, c.owner, c.repo, existingID, &github.IssueComment{
+				Body: github.String(body),
+			})
+			return err
 		})
 		if err != nil {
 			return fmt.Errorf("failed to update comment: %w", err)
@@ -71,8 +167,11 @@ func (c *Commenter) postComment(ctx context.Context, prNumber int, body string)
 		return nil
 	}
 
-	_, _, err = client.Issues.CreateComment(ctx, c.owner, c.repo, prNumber, &github.IssueComment{
-		Body: github.String(body),
+	err = withRateLimitRetry(ctx, func() error {
+		_, _, err := client.Issues.CreateComment(ctx, c.owner, c.repo, prNumber, &github.IssueComment{
+			Body: github.String(body),
+		})
+		return err
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create comment: %w", err)
@@ -82,20 +181,35 @@ func (c *Commenter) postComment(ctx context.Context, prNumber int, body string)
 }
 
 func (c *Commenter) findExistingComment(ctx context.Context, client *github.Client, prNumber int) (int64, error) {
-	comments, _, err := client.Issues.ListComments(ctx, c.owner, c.repo, prNumber, nil)
-	if err != nil {
-		return 0, err
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: commentsPerPage},
 	}
 
-	for _, comment := range comments {
-		if comment.Body != nil && strings.Contains(*comment.Body, commentMarker) {
-			if comment.ID != nil {
-				return *comment.ID, nil
+	for {
+		var comments []*github.IssueComment
+		var resp *github.Response
+		err := withRateLimitRetry(ctx, func() error {
+			var err error
+			comments, resp, err = client.Issues.ListComments(ctx, c.owner, c.repo, prNumber, opts)
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, comment := range comments {
+			if comment.Body != nil && strings.Contains(*comment.Body, commentMarker) {
+				if comment.ID != nil {
+					return *comment.ID, nil
+				}
 			}
 		}
-	}
 
-	return 0, nil
+		if resp == nil || resp.NextPage == 0 {
+			return 0, nil
+		}
+		opts.Page = resp.NextPage
+	}
 }
 
 func formatDeploymentComment(info DeploymentInfo) string {
@@ -104,17 +218,46 @@ func formatDeploymentComment(info DeploymentInfo) string {
 
 	sb.WriteString(commentMarker)
 	sb.WriteString("\n## DraftDeploy Preview\n\n")
-	fmt.Fprintf(&sb, "**URL:** http://%s\n\n", info.FQDN)
+	host := info.FQDN
+	if info.CustomDomain != "" {
+		host = info.CustomDomain
+		fmt.Fprintf(&sb, "**URL:** %s (Azure: %s)\n\n", PreviewURL(host, info.Services), PreviewURL(info.FQDN, info.Services))
+	} else {
+		fmt.Fprintf(&sb, "**URL:** %s\n\n", PreviewURL(host, info.Services))
+	}
+	switch info.Readiness {
+	case ReadinessReady:
+		sb.WriteString("**Status:** ✅ Ready\n\n")
+	case ReadinessProvisioning:
+		sb.WriteString("**Status:** ⏳ Provisioning\n\n")
+	case ReadinessFailed:
+		sb.WriteString("**Status:** ❌ Failed to start\n\n")
+	}
 
 	if len(info.Services) > 0 {
 		sb.WriteString("**Services:**\n")
 		for _, svc := range info.Services {
-			fmt.Fprintf(&sb, "- `%s` (ports: %s)\n", svc.Name, formatPorts(svc.Ports))
+			fmt.Fprintf(&sb, "- `%s` (ports: %s) — %s\n", svc.Name, formatServicePorts(svc), formatServiceURLs(host, svc))
 		}
 		sb.WriteString("\n")
 	}
 
-	fmt.Fprintf(&sb, "**Deploy time:** %s\n", info.DeployTime.Round(time.Second))
+	if info.ContainerGroup != "" {
+		fmt.Fprintf(&sb, "**Container group:** `%s`", info.ContainerGroup)
+		if info.ProvisioningState != "" {
+			fmt.Fprintf(&sb, " (%s)", info.ProvisioningState)
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "**Deploy time:** %s", info.DeployTime.Round(time.Second))
+	if info.ResourceGroupTime > 0 && info.ContainerGroupTime > 0 {
+		fmt.Fprintf(&sb, " (resource group: %s, containers: %s)", info.ResourceGroupTime.Round(time.Second), info.ContainerGroupTime.Round(time.Second))
+	}
+	sb.WriteString("\n")
+	if info.CostPerDay > 0 {
+		fmt.Fprintf(&sb, "**Estimated cost:** ~$%.2f/day while running (rough estimate)\n", info.CostPerDay)
+	}
+	writeLogsLink(&sb, info.LogsURL)
 
 	return sb.String()
 }
@@ -130,18 +273,172 @@ func formatTeardownComment(info DeploymentInfo) string {
 	if len(info.Services) > 0 {
 		sb.WriteString("**Services:**\n")
 		for _, svc := range info.Services {
-			fmt.Fprintf(&sb, "- `%s` (ports: %s)\n", svc.Name, formatPorts(svc.Ports))
+			fmt.Fprintf(&sb, "- `%s` (ports: %s)\n", svc.Name, formatServicePorts(svc))
 		}
 		sb.WriteString("\n")
 	}
 
-	fmt.Fprintf(&sb, "**Deploy time:** %s\n\n", info.DeployTime.Round(time.Second))
-	sb.WriteString("---\n")
+	fmt.Fprintf(&sb, "**Deploy time:** %s\n", info.DeployTime.Round(time.Second))
+	writeLogsLink(&sb, info.LogsURL)
+	sb.WriteString("\n---\n")
 	sb.WriteString("**Status:** Preview environment has been torn down.\n")
 
 	return sb.String()
 }
 
+func formatMergedComment(info DeploymentInfo, expires time.Time) string {
+	var sb strings.Builder
+	sb.Grow(512)
+
+	sb.WriteString(commentMarker)
+	sb.WriteString("\n## DraftDeploy Preview\n\n")
+	if info.FQDN != "" {
+		fmt.Fprintf(&sb, "**URL:** http://%s\n\n", info.FQDN)
+	}
+	fmt.Fprintf(&sb, "**Status:** 🔀 Merged. The preview stays up until %s for verification and is then deleted.\n", expires.UTC().Format("2006-01-02 15:04 MST"))
+	writeLogsLink(&sb, info.LogsURL)
+
+	return sb.String()
+}
+
+func formatSkippedComment(reason string) string {
+	var sb strings.Builder
+	sb.Grow(256)
+
+	sb.WriteString(commentMarker)
+	sb.WriteString("\n## DraftDeploy Preview\n\n")
+	sb.WriteString("**Status:** ⏭️ Preview skipped\n\n")
+	sb.WriteString(reason)
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+func formatFailureComment(errSummary, hint, logsURL string, logs []ContainerLog) string {
+	var sb strings.Builder
+	sb.Grow(512)
+
+	sb.WriteString(commentMarker)
+	sb.WriteString("\n## DraftDeploy Preview\n\n")
+	sb.WriteString("**Status:** ❌ Deployment failed\n\n")
+	sb.WriteString("**Error:**\n```\n")
+	sb.WriteString(truncate(errSummary, maxErrorSummaryLen))
+	sb.WriteString("\n```\n")
+	if hint != "" {
+		fmt.Fprintf(&sb, "\n**Hint:** %s\n", hint)
+	}
+
+	for _, l := range logs {
+		fmt.Fprintf(&sb, "\n<details><summary>Logs for <code>%s</code></summary>\n\n```\n", l.Container)
+		sb.WriteString(truncateHead(l.Output, maxContainerLogLen))
+		sb.WriteString("\n```\n\n</details>\n")
+	}
+
+	if logsURL != "" {
+		fmt.Fprintf(&sb, "\n[View workflow logs](%s)\n", logsURL)
+	}
+
+	return sb.String()
+}
+
+func writeLogsLink(sb *strings.Builder, logsURL string) {
+	if logsURL != "" {
+		fmt.Fprintf(sb, "**Logs:** [workflow run](%s)\n", logsURL)
+	}
+}
+
+// truncateHead keeps the end of s, where a crash usually shows up.
+func truncateHead(s string, maxLen int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= maxLen {
+		return s
+	}
+	return "…" + s[len(s)-maxLen:]
+}
+
+func truncate(s string, maxLen int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "…"
+}
+
+func formatServiceURLs(fqdn string, svc ServiceInfo) string {
+	if svc.Path != "" {
+		return fmt.Sprintf("http://%s%s", fqdn, svc.Path)
+	}
+	if !svc.Public || len(svc.Ports)+len(svc.UDPPorts) == 0 {
+		return "internal only"
+	}
+	if svc.IngressOnly {
+		return serviceURL(fqdn, svc.IngressPort)
+	}
+	urls := make([]string, 0, len(svc.Ports)+len(svc.UDPPorts))
+	for _, p := range svc.Ports {
+		urls = append(urls, serviceURL(fqdn, p))
+	}
+	for _, p := range svc.UDPPorts {
+		urls = append(urls, fmt.Sprintf("udp://%s:%d", fqdn, p))
+	}
+	return strings.Join(urls, ", ")
+}
+
+// PrimaryPort is the TCP port the preview URL points at: the labeled ingress
+// port of a public service, else 80 when a public service listens on it,
+// otherwise the first port of the first public service.
+func PrimaryPort(services []ServiceInfo) (int32, bool) {
+	for _, svc := range services {
+		if svc.Public && svc.IngressPort != 0 {
+			return svc.IngressPort, true
+		}
+	}
+	var first int32
+	for _, svc := range services {
+		if !svc.Public || len(svc.Ports) == 0 {
+			continue
+		}
+		if slices.Contains(svc.Ports, 80) {
+			return 80, true
+		}
+		if first == 0 {
+			first = svc.Ports[0]
+		}
+	}
+	return first, first != 0
+}
+
+// PreviewURL is the link to the preview, with the port from PrimaryPort
+// unless it is 80.
+func PreviewURL(host string, services []ServiceInfo) string {
+	port, ok := PrimaryPort(services)
+	if !ok {
+		port = 80
+	}
+	return serviceURL(host, port)
+}
+
+func serviceURL(fqdn string, port int32) string {
+	if port == 80 {
+		return fmt.Sprintf("http://%s", fqdn)
+	}
+	return fmt.Sprintf("http://%s:%d", fqdn, port)
+}
+
+func formatServicePorts(svc ServiceInfo) string {
+	if len(svc.UDPPorts) == 0 {
+		return formatPorts(svc.Ports)
+	}
+	strs := make([]string, 0, len(svc.Ports)+len(svc.UDPPorts))
+	for _, p := range svc.Ports {
+		strs = append(strs, fmt.Sprintf("%d", p))
+	}
+	for _, p := range svc.UDPPorts {
+		strs = append(strs, fmt.Sprintf("%d/udp", p))
+	}
+	return strings.Join(strs, ", ")
+}
+
 func formatPorts(ports []int32) string {
 	if len(ports) == 0 {
 		return "none"
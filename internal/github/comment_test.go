@@ -1,9 +1,16 @@
 package github
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/google/go-github/v57/github"
 )
 
 func TestFormatDeploymentComment(t *testing.T) {
@@ -16,6 +23,8 @@ func TestFormatDeploymentComment(t *testing.T) {
 			{Name: "api", Ports: []int32{3000, 3001}},
 		},
 		DeployTime: 45 * time.Second,
+		LogsURL:    "https://github.com/owner/repo/actions/runs/1",
+		CostPerDay: 1.234,
 	}
 
 	body := formatDeploymentComment(info)
@@ -39,6 +48,148 @@ func TestFormatDeploymentComment(t *testing.T) {
 	if !strings.Contains(body, "45s") {
 		t.Error("expected comment to contain deploy time")
 	}
+
+	if !strings.Contains(body, "~$1.23/day") {
+		t.Error("expected comment to contain cost estimate")
+	}
+
+	if !strings.Contains(body, "**Logs:** [workflow run](https://github.com/owner/repo/actions/runs/1)") {
+		t.Error("expected comment to link to workflow logs")
+	}
+}
+
+func TestFormatDeploymentComment_PhaseTimes(t *testing.T) {
+	t.Parallel()
+
+	info := DeploymentInfo{FQDN: "app.eastus.azurecontainer.io", DeployTime: 95 * time.Second}
+	if body := formatDeploymentComment(info); !strings.Contains(body, "**Deploy time:** 1m35s\n") {
+		t.Errorf("expected plain deploy time without phase times, got:\n%s", body)
+	}
+
+	info.ResourceGroupTime = 5 * time.Second
+	info.ContainerGroupTime = 80 * time.Second
+	if body := formatDeploymentComment(info); !strings.Contains(body, "**Deploy time:** 1m35s (resource group: 5s, containers: 1m20s)\n") {
+		t.Errorf("expected phase times in comment, got:\n%s", body)
+	}
+}
+
+func TestFormatDeploymentComment_Readiness(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		readiness string
+		want      string
+	}{
+		{ReadinessReady, "**Status:** ✅ Ready"},
+		{ReadinessProvisioning, "**Status:** ⏳ Provisioning"},
+		{ReadinessFailed, "**Status:** ❌ Failed to start"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.readiness, func(t *testing.T) {
+			t.Parallel()
+
+			body := formatDeploymentComment(DeploymentInfo{FQDN: "app.eastus.azurecontainer.io", Readiness: tt.readiness})
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("expected comment to contain %q, got:\n%s", tt.want, body)
+			}
+		})
+	}
+
+	if body := formatDeploymentComment(DeploymentInfo{FQDN: "app.eastus.azurecontainer.io"}); strings.Contains(body, "**Status:**") {
+		t.Error("expected no status line when readiness was not checked")
+	}
+}
+
+func TestFormatDeploymentComment_ContainerGroup(t *testing.T) {
+	t.Parallel()
+
+	body := formatDeploymentComment(DeploymentInfo{
+		FQDN:              "dd-acme-app-pr12.eastus.azurecontainer.io",
+		ContainerGroup:    "dd-acme-app-pr12",
+		ProvisioningState: "Succeeded",
+	})
+	if !strings.Contains(body, "**Container group:** `dd-acme-app-pr12` (Succeeded)") {
+		t.Errorf("expected container group line, got:\n%s", body)
+	}
+
+	if body := formatDeploymentComment(DeploymentInfo{FQDN: "app.eastus.azurecontainer.io"}); strings.Contains(body, "**Container group:**") {
+		t.Error("expected no container group line when the name is unknown")
+	}
+}
+
+func TestFormatDeploymentComment_CustomDomain(t *testing.T) {
+	t.Parallel()
+
+	body := formatDeploymentComment(DeploymentInfo{
+		FQDN:         "dd-acme-app-pr12.eastus.azurecontainer.io",
+		CustomDomain: "pr-12.preview.example.com",
+		Services:     []ServiceInfo{{Name: "web", Ports: []int32{8080}, Public: true}},
+	})
+
+	if !strings.Contains(body, "**URL:** http://pr-12.preview.example.com:8080 (Azure: http://dd-acme-app-pr12.eastus.azurecontainer.io:8080)") {
+		t.Errorf("expected custom domain to be the primary URL, got:\n%s", body)
+	}
+	if !strings.Contains(body, "http://pr-12.preview.example.com:8080") {
+		t.Errorf("expected service URLs to use the custom domain, got:\n%s", body)
+	}
+}
+
+func TestPreviewURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		services []ServiceInfo
+		want     string
+	}{
+		{"no services", nil, "http://example.com"},
+		{"port 80", []ServiceInfo{{Name: "web", Ports: []int32{80}, Public: true}}, "http://example.com"},
+		{"other port", []ServiceInfo{{Name: "api", Ports: []int32{3000, 3001}, Public: true}}, "http://example.com:3000"},
+		{
+			name: "prefers port 80",
+			services: []ServiceInfo{
+				{Name: "api", Ports: []int32{3000}, Public: true},
+				{Name: "web", Ports: []int32{80}, Public: true},
+			},
+			want: "http://example.com",
+		},
+		{
+			name: "skips internal and UDP services",
+			services: []ServiceInfo{
+				{Name: "db", Ports: []int32{5432}},
+				{Name: "dns", UDPPorts: []int32{53}, Public: true},
+				{Name: "api", Ports: []int32{8080}, Public: true},
+			},
+			want: "http://example.com:8080",
+		},
+		{
+			name: "prefers labeled ingress port",
+			services: []ServiceInfo{
+				{Name: "web", Ports: []int32{80}, Public: true},
+				{Name: "app", Ports: []int32{8080, 9090}, Public: true, IngressPort: 9090},
+			},
+			want: "http://example.com:9090",
+		},
+		{
+			name: "ignores ingress port of internal service",
+			services: []ServiceInfo{
+				{Name: "admin", Ports: []int32{9090}, IngressPort: 9090},
+				{Name: "api", Ports: []int32{3000}, Public: true},
+			},
+			want: "http://example.com:3000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := PreviewURL("example.com", tt.services); got != tt.want {
+				t.Errorf("PreviewURL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
 }
 
 func TestFormatTeardownComment(t *testing.T) {
@@ -116,3 +267,207 @@ func TestFormatPorts(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatDeploymentComment_ServiceURLs(t *testing.T) {
+	t.Parallel()
+
+	info := DeploymentInfo{
+		FQDN: "myapp-pr123.eastus.azurecontainer.io",
+		Services: []ServiceInfo{
+			{Name: "frontend", Ports: []int32{80}, Public: true},
+			{Name: "api", Ports: []int32{3000}, Public: true},
+			{Name: "postgres", Ports: []int32{5432}},
+			{Name: "game", UDPPorts: []int32{27015}, Public: true},
+			{Name: "voice", Ports: []int32{8080}, UDPPorts: []int32{9987}, Public: true},
+			{Name: "dns", UDPPorts: []int32{53}},
+			{Name: "admin", Ports: []int32{9000}, Path: "/admin"},
+			{Name: "gateway", Ports: []int32{80, 8443, 9090}, Public: true},
+			{Name: "web", Ports: []int32{80, 8080}, Public: true, IngressPort: 8080, IngressOnly: true},
+		},
+	}
+
+	body := formatDeploymentComment(info)
+
+	expected := []string{
+		"- `frontend` (ports: 80) — http://myapp-pr123.eastus.azurecontainer.io\n",
+		"- `api` (ports: 3000) — http://myapp-pr123.eastus.azurecontainer.io:3000\n",
+		"- `postgres` (ports: 5432) — internal only\n",
+		"- `game` (ports: 27015/udp) — udp://myapp-pr123.eastus.azurecontainer.io:27015\n",
+		"- `voice` (ports: 8080, 9987/udp) — http://myapp-pr123.eastus.azurecontainer.io:8080, udp://myapp-pr123.eastus.azurecontainer.io:9987\n",
+		"- `dns` (ports: 53/udp) — internal only\n",
+		"- `admin` (ports: 9000) — http://myapp-pr123.eastus.azurecontainer.io/admin\n",
+		"- `gateway` (ports: 80, 8443, 9090) — http://myapp-pr123.eastus.azurecontainer.io, http://myapp-pr123.eastus.azurecontainer.io:8443, http://myapp-pr123.eastus.azurecontainer.io:9090\n",
+		"- `web` (ports: 80, 8080) — http://myapp-pr123.eastus.azurecontainer.io:8080\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("expected comment to contain %q, got:\n%s", line, body)
+		}
+	}
+}
+
+func TestFormatFailureComment(t *testing.T) {
+	t.Parallel()
+
+	body := formatFailureComment("failed to deploy: QuotaExceeded", "Delete unused previews.", "https://github.com/owner/repo/actions/runs/1", nil)
+
+	if !strings.Contains(body, commentMarker) {
+		t.Error("expected comment to contain marker")
+	}
+
+	if !strings.Contains(body, "❌") {
+		t.Error("expected comment to contain failure status")
+	}
+
+	if !strings.Contains(body, "QuotaExceeded") {
+		t.Error("expected comment to contain error summary")
+	}
+
+	if !strings.Contains(body, "**Hint:** Delete unused previews.") {
+		t.Error("expected comment to contain hint")
+	}
+
+	if !strings.Contains(body, "[View workflow logs](https://github.com/owner/repo/actions/runs/1)") {
+		t.Error("expected comment to link to workflow logs")
+	}
+}
+
+func TestFormatFailureComment_Truncated(t *testing.T) {
+	t.Parallel()
+
+	body := formatFailureComment(strings.Repeat("x", 2*maxErrorSummaryLen), "", "", nil)
+
+	if strings.Contains(body, strings.Repeat("x", maxErrorSummaryLen+1)) {
+		t.Error("expected long error summary to be truncated")
+	}
+
+	if strings.Contains(body, "View workflow logs") {
+		t.Error("expected no logs link without a URL")
+	}
+
+	if strings.Contains(body, "**Hint:**") {
+		t.Error("expected no hint line without a hint")
+	}
+}
+
+func TestFormatMergedComment(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Date(2025, 3, 2, 15, 4, 0, 0, time.UTC)
+	body := formatMergedComment(DeploymentInfo{FQDN: "myapp-pr123.eastus.azurecontainer.io"}, expires)
+
+	if !strings.Contains(body, commentMarker) {
+		t.Error("expected comment to contain marker")
+	}
+	if !strings.Contains(body, "**URL:** http://myapp-pr123.eastus.azurecontainer.io") {
+		t.Error("expected comment to keep the preview URL")
+	}
+	if !strings.Contains(body, "until 2025-03-02 15:04 UTC") {
+		t.Errorf("expected expiry time in comment, got:\n%s", body)
+	}
+
+	if body := formatMergedComment(DeploymentInfo{}, expires); strings.Contains(body, "**URL:**") {
+		t.Error("expected no URL line without an FQDN")
+	}
+}
+
+func TestFormatSkippedComment(t *testing.T) {
+	t.Parallel()
+
+	body := formatSkippedComment("This repository is not allowed to create preview environments.")
+
+	if !strings.Contains(body, commentMarker) {
+		t.Error("expected comment to contain marker")
+	}
+	if !strings.Contains(body, "**Status:** ⏭️ Preview skipped\n\nThis repository is not allowed") {
+		t.Errorf("expected skipped status and reason, got:\n%s", body)
+	}
+}
+
+func TestFormatFailureComment_ContainerLogs(t *testing.T) {
+	t.Parallel()
+
+	longLog := strings.Repeat("x", 2*maxContainerLogLen) + "\npanic: out of memory"
+	body := formatFailureComment("failed to deploy: container exited", "", "", []ContainerLog{
+		{Container: "api", Output: "listening on :3000\npanic: boom\n"},
+		{Container: "worker", Output: longLog},
+	})
+
+	if !strings.Contains(body, "<details><summary>Logs for <code>api</code></summary>\n\n```\nlistening on :3000\npanic: boom\n```\n\n</details>") {
+		t.Errorf("expected collapsed api logs, got:\n%s", body)
+	}
+	if !strings.Contains(body, "panic: out of memory") {
+		t.Error("expected the end of a long log to be kept")
+	}
+	if strings.Contains(body, strings.Repeat("x", maxContainerLogLen+1)) {
+		t.Error("expected long log to be truncated")
+	}
+}
+
+func newTestClient(t *testing.T, handler http.Handler) *github.Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse server URL: %v", err)
+	}
+	client.BaseURL = baseURL
+	return client
+}
+
+func TestFindExistingComment_Paginated(t *testing.T) {
+	t.Parallel()
+
+	var pages []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/issues/5/comments" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+
+		switch page {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2>; rel="next"`, r.Host, r.URL.Path))
+			fmt.Fprint(w, `[{"id": 1, "body": "looks good"}, {"id": 2, "body": "ship it"}]`)
+		case "2":
+			fmt.Fprintf(w, `[{"id": 3, "body": "nit"}, {"id": 4, "body": "%s\n## DraftDeploy Preview"}]`, commentMarker)
+		default:
+			t.Errorf("unexpected page %q", page)
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+
+	c := NewCommenter("fake-token", "owner", "repo")
+	id, err := c.findExistingComment(context.Background(), client, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 4 {
+		t.Errorf("findExistingComment() = %d, want 4", id)
+	}
+	if len(pages) != 2 {
+		t.Errorf("expected 2 pages to be requested, got %v", pages)
+	}
+}
+
+func TestFindExistingComment_NotFound(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": 1, "body": "looks good"}]`)
+	}))
+
+	c := NewCommenter("fake-token", "owner", "repo")
+	id, err := c.findExistingComment(context.Background(), client, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 0 {
+		t.Errorf("findExistingComment() = %d, want 0", id)
+	}
+}
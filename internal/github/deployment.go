@@ -0,0 +1,99 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v57/github"
+)
+
+const (
+	DeploymentStateInProgress = "in_progress"
+	DeploymentStateSuccess    = "success"
+	DeploymentStateFailure    = "failure"
+	DeploymentStateInactive   = "inactive"
+
+	deploymentsPerPage = 100
+)
+
+func EnvironmentName(prNumber int) string {
+	return fmt.Sprintf("pr-%d", prNumber)
+}
+
+func BranchEnvironmentName(slug string) string {
+	return fmt.Sprintf("branch-%s", slug)
+}
+
+func (c *Commenter) CreateDeployment(ctx context.Context, ref, environment string) (int64, error) {
+	return c.createDeployment(ctx, c.getClient(ctx), ref, environment)
+}
+
+func (c *Commenter) createDeployment(ctx context.Context, client *github.Client, ref, environment string) (int64, error) {
+	deployment, _, err := client.Repositories.CreateDeployment(ctx, c.owner, c.repo, &github.DeploymentRequest{
+		Ref:                  github.String(ref),
+		Environment:          github.String(environment),
+		Description:          github.String("DraftDeploy preview"),
+		AutoMerge:            github.Bool(false),
+		RequiredContexts:     &[]string{},
+		TransientEnvironment: github.Bool(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create deployment: %w", err)
+	}
+	if deployment.ID == nil {
+		return 0, fmt.Errorf("created deployment has no ID")
+	}
+
+	return *deployment.ID, nil
+}
+
+func (c *Commenter) SetDeploymentStatus(ctx context.Context, deploymentID int64, state, envURL string) error {
+	return c.setDeploymentStatus(ctx, c.getClient(ctx), deploymentID, state, envURL)
+}
+
+func (c *Commenter) setDeploymentStatus(ctx context.Context, client *github.Client, deploymentID int64, state, envURL string) error {
+	req := &github.DeploymentStatusRequest{
+		State:        github.String(state),
+		AutoInactive: github.Bool(true),
+	}
+	if envURL != "" {
+		req.EnvironmentURL = github.String(envURL)
+	}
+
+	if _, _, err := client.Repositories.CreateDeploymentStatus(ctx, c.owner, c.repo, deploymentID, req); err != nil {
+		return fmt.Errorf("failed to set deployment status: %w", err)
+	}
+	return nil
+}
+
+func (c *Commenter) DeactivateDeployments(ctx context.Context, environment string) error {
+	return c.deactivateDeployments(ctx, c.getClient(ctx), environment)
+}
+
+func (c *Commenter) deactivateDeployments(ctx context.Context, client *github.Client, environment string) error {
+	opts := &github.DeploymentsListOptions{
+		Environment: environment,
+		ListOptions: github.ListOptions{PerPage: deploymentsPerPage},
+	}
+
+	for {
+		deployments, resp, err := client.Repositories.ListDeployments(ctx, c.owner, c.repo, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list deployments: %w", err)
+		}
+
+		for _, deployment := range deployments {
+			if deployment.ID == nil {
+				continue
+			}
+			if err := c.setDeploymentStatus(ctx, client, *deployment.ID, DeploymentStateInactive, ""); err != nil {
+				return err
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			return nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,182 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestEnvironmentName(t *testing.T) {
+	t.Parallel()
+
+	if got := EnvironmentName(42); got != "pr-42" {
+		t.Errorf("EnvironmentName(42) = %q, want %q", got, "pr-42")
+	}
+	if got := BranchEnvironmentName("feature-login"); got != "branch-feature-login" {
+		t.Errorf("BranchEnvironmentName() = %q, want %q", got, "branch-feature-login")
+	}
+}
+
+func decodeBody(t *testing.T, r *http.Request) map[string]any {
+	t.Helper()
+
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		t.Errorf("failed to decode request body: %v", err)
+	}
+	return body
+}
+
+func TestCreateDeployment(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]any
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/owner/repo/deployments" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		body = decodeBody(t, r)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": 7}`)
+	}))
+
+	c := NewCommenter("fake-token", "owner", "repo")
+	id, err := c.createDeployment(context.Background(), client, "feature/login", "pr-42")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 7 {
+		t.Errorf("createDeployment() = %d, want 7", id)
+	}
+	if body["ref"] != "feature/login" || body["environment"] != "pr-42" {
+		t.Errorf("expected ref and environment in request, got %v", body)
+	}
+	if body["transient_environment"] != true || body["auto_merge"] != false {
+		t.Errorf("expected a transient deployment without auto merge, got %v", body)
+	}
+	if contexts, ok := body["required_contexts"].([]any); !ok || len(contexts) != 0 {
+		t.Errorf("expected empty required_contexts, got %v", body["required_contexts"])
+	}
+}
+
+func TestCreateDeployment_Error(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"message": "Conflict: Commit status checks failed"}`)
+	}))
+
+	c := NewCommenter("fake-token", "owner", "repo")
+	if _, err := c.createDeployment(context.Background(), client, "main", "pr-42"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSetDeploymentStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		state  string
+		envURL string
+	}{
+		{name: "success with URL", state: DeploymentStateSuccess, envURL: "http://dd-pr42.eastus.azurecontainer.io"},
+		{name: "failure without URL", state: DeploymentStateFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var body map[string]any
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/repos/owner/repo/deployments/7/statuses" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				body = decodeBody(t, r)
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"id": 1}`)
+			}))
+
+			c := NewCommenter("fake-token", "owner", "repo")
+			if err := c.setDeploymentStatus(context.Background(), client, 7, tt.state, tt.envURL); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if body["state"] != tt.state || body["auto_inactive"] != true {
+				t.Errorf("expected state %q with auto_inactive, got %v", tt.state, body)
+			}
+			url, ok := body["environment_url"]
+			if tt.envURL == "" && ok {
+				t.Errorf("expected no environment_url, got %v", url)
+			}
+			if tt.envURL != "" && url != tt.envURL {
+				t.Errorf("environment_url = %v, want %q", url, tt.envURL)
+			}
+		})
+	}
+}
+
+func TestDeactivateDeployments_Paginated(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var pages []string
+	var inactive []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method == http.MethodPost {
+			if state := decodeBody(t, r)["state"]; state != DeploymentStateInactive {
+				t.Errorf("expected inactive state, got %v", state)
+			}
+			inactive = append(inactive, r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": 1}`)
+			return
+		}
+
+		if r.URL.Path != "/repos/owner/repo/deployments" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("environment") != "pr-42" || query.Get("per_page") != "100" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		page := query.Get("page")
+		pages = append(pages, page)
+
+		switch page {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?environment=pr-42&per_page=100&page=2>; rel="next"`, r.Host, r.URL.Path))
+			fmt.Fprint(w, `[{"id": 1}, {"id": 2}]`)
+		case "2":
+			fmt.Fprint(w, `[{"id": 3}, {}]`)
+		default:
+			t.Errorf("unexpected page %q", page)
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+
+	c := NewCommenter("fake-token", "owner", "repo")
+	if err := c.deactivateDeployments(context.Background(), client, "pr-42"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(pages, []string{"", "2"}) {
+		t.Errorf("expected both pages to be fetched, got %q", pages)
+	}
+	want := []string{
+		"/repos/owner/repo/deployments/1/statuses",
+		"/repos/owner/repo/deployments/2/statuses",
+		"/repos/owner/repo/deployments/3/statuses",
+	}
+	if !slices.Equal(inactive, want) {
+		t.Errorf("inactivated %v, want %v", inactive, want)
+	}
+}
@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+const (
+	maxRateLimitRetries     = 3
+	maxRateLimitWait        = 2 * time.Minute
+	minRateLimitWait        = time.Second
+	defaultSecondaryBackoff = time.Minute
+)
+
+func withRateLimitRetry(ctx context.Context, operation func() error) error {
+	return retryRateLimited(ctx, maxRateLimitRetries, time.Now, sleepContext, operation)
+}
+
+func retryRateLimited(ctx context.Context, maxRetries int, now func() time.Time, sleep func(context.Context, time.Duration) error, operation func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := operation()
+		if err == nil || attempt >= maxRetries {
+			return err
+		}
+
+		wait, ok := rateLimitWait(err, now())
+		if !ok || wait > maxRateLimitWait {
+			return err
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func rateLimitWait(err error, now time.Time) (time.Duration, bool) {
+	var wait time.Duration
+
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	switch {
+	case errors.As(err, &rateErr):
+		wait = rateErr.Rate.Reset.Sub(now)
+	case errors.As(err, &abuseErr):
+		wait = abuseErr.GetRetryAfter()
+		if wait == 0 {
+			wait = defaultSecondaryBackoff
+		}
+	default:
+		return 0, false
+	}
+
+	return max(wait, minRateLimitWait), true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
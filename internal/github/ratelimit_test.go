@@ -0,0 +1,94 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+func TestRateLimitWait(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	retryAfter := 30 * time.Second
+
+	tests := []struct {
+		name   string
+		err    error
+		want   time.Duration
+		wantOK bool
+	}{
+		{"primary", &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: now.Add(45 * time.Second)}}}, 45 * time.Second, true},
+		{"primary already reset", &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: now.Add(-time.Second)}}}, minRateLimitWait, true},
+		{"secondary with retry-after", &github.AbuseRateLimitError{RetryAfter: &retryAfter}, retryAfter, true},
+		{"secondary without retry-after", &github.AbuseRateLimitError{}, defaultSecondaryBackoff, true},
+		{"other error", errors.New("404 Not Found"), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := rateLimitWait(tt.err, now)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("rateLimitWait() = (%s, %t), want (%s, %t)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryRateLimited(t *testing.T) {
+	t.Parallel()
+
+	retryAfter := 5 * time.Second
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantSlept time.Duration
+		wantErr   bool
+	}{
+		{"success", []error{nil}, 1, 0, false},
+		{"retries secondary limit", []error{&github.AbuseRateLimitError{RetryAfter: &retryAfter}, nil}, 2, retryAfter, false},
+		{"fails fast on other errors", []error{errors.New("boom"), nil}, 1, 0, true},
+		{"gives up after max retries", []error{
+			&github.AbuseRateLimitError{RetryAfter: &retryAfter},
+			&github.AbuseRateLimitError{RetryAfter: &retryAfter},
+			&github.AbuseRateLimitError{RetryAfter: &retryAfter},
+			&github.AbuseRateLimitError{RetryAfter: &retryAfter},
+		}, 4, 3 * retryAfter, true},
+		{"does not wait past the cap", []error{&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: time.Now().Add(time.Hour)}}}, nil}, 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int
+			var slept time.Duration
+			sleep := func(_ context.Context, d time.Duration) error {
+				slept += d
+				return nil
+			}
+
+			err := retryRateLimited(context.Background(), maxRateLimitRetries, time.Now, sleep, func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("retryRateLimited() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+			if slept != tt.wantSlept {
+				t.Errorf("expected to sleep %s, slept %s", tt.wantSlept, slept)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v57/github"
+)
+
+const (
+	StatusContext = "draftdeploy/preview"
+
+	CommitStatePending = "pending"
+	CommitStateSuccess = "success"
+	CommitStateFailure = "failure"
+)
+
+func (c *Commenter) SetCommitStatus(ctx context.Context, sha, state, targetURL, description string) error {
+	return c.setCommitStatus(ctx, c.getClient(ctx), sha, state, targetURL, description)
+}
+
+func (c *Commenter) setCommitStatus(ctx context.Context, client *github.Client, sha, state, targetURL, description string) error {
+	status := &github.RepoStatus{
+		State:       github.String(state),
+		Description: github.String(description),
+		Context:     github.String(StatusContext),
+	}
+	if targetURL != "" {
+		status.TargetURL = github.String(targetURL)
+	}
+
+	if _, _, err := client.Repositories.CreateStatus(ctx, c.owner, c.repo, sha, status); err != nil {
+		return fmt.Errorf("failed to set commit status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestSetCommitStatus(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]any
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/owner/repo/statuses/abc123" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		body = decodeBody(t, r)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": 1}`)
+	}))
+
+	c := NewCommenter("fake-token", "owner", "repo")
+	err := c.setCommitStatus(context.Background(), client, "abc123", CommitStateSuccess, "http://dd-pr42.eastus.azurecontainer.io", "Preview ready")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{
+		"state":       CommitStateSuccess,
+		"target_url":  "http://dd-pr42.eastus.azurecontainer.io",
+		"description": "Preview ready",
+		"context":     StatusContext,
+	}
+	for key, value := range want {
+		if body[key] != value {
+			t.Errorf("%s = %v, want %v", key, body[key], value)
+		}
+	}
+}
+
+func TestSetCommitStatus_Error(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		fmt.Fprint(w, `{"message": "No commit found for SHA: abc123"}`)
+	}))
+
+	c := NewCommenter("fake-token", "owner", "repo")
+	err := c.setCommitStatus(context.Background(), client, "abc123", CommitStatePending, "", "Deploying")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,31 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DeploymentSummary renders the deployment comment for the Actions step
+// summary, without the comment marker and with the preview's resource group.
+func DeploymentSummary(info DeploymentInfo, resourceGroup string) string {
+	body := strings.TrimPrefix(formatDeploymentComment(info), commentMarker+"\n")
+	return body + fmt.Sprintf("**Resource group:** `%s`\n", resourceGroup)
+}
+
+// TeardownSummary renders the Actions step summary for a teardown.
+func TeardownSummary(resourceGroup string, existed bool, duration time.Duration) string {
+	var sb strings.Builder
+	sb.Grow(256)
+
+	sb.WriteString("## DraftDeploy Preview\n\n")
+	if existed {
+		sb.WriteString("**Status:** Preview environment has been torn down.\n\n")
+	} else {
+		sb.WriteString("**Status:** No preview environment was found.\n\n")
+	}
+	fmt.Fprintf(&sb, "**Resource group:** `%s`\n", resourceGroup)
+	fmt.Fprintf(&sb, "**Teardown time:** %s\n", duration.Round(time.Second))
+
+	return sb.String()
+}
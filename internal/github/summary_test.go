@@ -0,0 +1,58 @@
+package github
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeploymentSummary(t *testing.T) {
+	t.Parallel()
+
+	body := DeploymentSummary(DeploymentInfo{
+		FQDN:       "myapp-pr123.eastus.azurecontainer.io",
+		Services:   []ServiceInfo{{Name: "web", Ports: []int32{80}}},
+		DeployTime: 45 * time.Second,
+	}, "draftdeploy-acme-app-pr-123")
+
+	if strings.Contains(body, commentMarker) {
+		t.Error("expected summary to omit the comment marker")
+	}
+	for _, want := range []string{
+		"## DraftDeploy Preview",
+		"http://myapp-pr123.eastus.azurecontainer.io",
+		"`web`",
+		"**Deploy time:** 45s",
+		"**Resource group:** `draftdeploy-acme-app-pr-123`",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected summary to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestTeardownSummary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		existed bool
+		want    string
+	}{
+		{"deleted", true, "has been torn down"},
+		{"absent", false, "No preview environment was found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			body := TeardownSummary("draftdeploy-acme-app-pr-123", tt.existed, 90*time.Second)
+			for _, want := range []string{tt.want, "`draftdeploy-acme-app-pr-123`", "**Teardown time:** 1m30s"} {
+				if !strings.Contains(body, want) {
+					t.Errorf("expected summary to contain %q, got:\n%s", want, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package github
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	CommentStatusDeployed = "deployed"
+	CommentStatusTornDown = "torn_down"
+)
+
+// CommentData is the value a custom comment template is executed with.
+type CommentData struct {
+	Status         string
+	URL            string
+	FQDN           string
+	CustomDomain   string
+	ContainerGroup string
+	Services       []ServiceInfo
+	Duration       time.Duration
+	SHA            string
+	LogsURL        string
+	Readiness      string
+	CostPerDay     float64
+}
+
+// ParseCommentTemplate parses a text/template for deployment and teardown
+// comments. It is executed once against empty data so that unknown fields
+// fail here rather than when the comment is posted.
+func ParseCommentTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("comment").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse comment template: %w", err)
+	}
+	if err := tmpl.Execute(&strings.Builder{}, CommentData{}); err != nil {
+		return nil, fmt.Errorf("failed to execute comment template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// SetTemplate replaces the built-in deployment and teardown comments.
+// Failure, skipped and merge comments keep the built-in wording.
+func (c *Commenter) SetTemplate(tmpl *template.Template) {
+	c.template = tmpl
+}
+
+func newCommentData(info DeploymentInfo, status string) CommentData {
+	host := cmp.Or(info.CustomDomain, info.FQDN)
+	data := CommentData{
+		Status:         status,
+		FQDN:           info.FQDN,
+		CustomDomain:   info.CustomDomain,
+		ContainerGroup: info.ContainerGroup,
+		Services:       info.Services,
+		Duration:       info.DeployTime.Round(time.Second),
+		SHA:            info.SHA,
+		LogsURL:        info.LogsURL,
+		Readiness:      info.Readiness,
+		CostPerDay:     info.CostPerDay,
+	}
+	if host != "" {
+		data.URL = PreviewURL(host, info.Services)
+	}
+	return data
+}
+
+func renderComment(tmpl *template.Template, data CommentData) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(commentMarker)
+	sb.WriteString("\n")
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render comment template: %w", err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,116 @@
+package github
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCommentTemplate = `![Acme](https://acme.example/logo.png)
+{{if eq .Status "deployed"}}Your preview is live at {{.URL}} after {{.Duration}} (commit {{.SHA}}).
+{{range .Services}}* {{.Name}}
+{{end}}{{else}}The preview for this PR is gone.{{end}}
+[Give feedback](https://acme.example/feedback)`
+
+func TestRenderComment(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := ParseCommentTemplate(testCommentTemplate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info := DeploymentInfo{
+		FQDN:       "dd-acme-app-pr7.eastus.azurecontainer.io",
+		Services:   []ServiceInfo{{Name: "web", Ports: []int32{3000}, Public: true}, {Name: "db", Ports: []int32{5432}}},
+		DeployTime: 61400 * time.Millisecond,
+		SHA:        "abc1234",
+	}
+
+	tests := []struct {
+		name   string
+		status string
+		want   []string
+	}{
+		{
+			name:   "deployed",
+			status: CommentStatusDeployed,
+			want: []string{
+				"Your preview is live at http://dd-acme-app-pr7.eastus.azurecontainer.io:3000 after 1m1s (commit abc1234).",
+				"* web\n* db\n",
+				"[Give feedback]",
+			},
+		},
+		{
+			name:   "torn down",
+			status: CommentStatusTornDown,
+			want:   []string{"The preview for this PR is gone."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			body, err := renderComment(tmpl, newCommentData(info, tt.status))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(body, commentMarker+"\n") {
+				t.Errorf("expected comment to start with the marker, got:\n%s", body)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("expected comment to contain %q, got:\n%s", want, body)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCommentTemplate_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"syntax", "{{if .URL}}"},
+		{"unknown field", "{{.Hostname}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := ParseCommentTemplate(tt.text); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestCommenter_DeploymentComment(t *testing.T) {
+	t.Parallel()
+
+	info := DeploymentInfo{FQDN: "app.eastus.azurecontainer.io"}
+	c := NewCommenter("token", "acme", "app")
+
+	body, err := c.deploymentComment(info)
+	if err != nil || body != formatDeploymentComment(info) {
+		t.Errorf("expected the built-in comment without a template, got %q, %v", body, err)
+	}
+
+	tmpl, err := ParseCommentTemplate("Preview: {{.URL}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.SetTemplate(tmpl)
+	body, err = c.deploymentComment(info)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := commentMarker + "\nPreview: http://app.eastus.azurecontainer.io"; body != want {
+		t.Errorf("deploymentComment() = %q, want %q", body, want)
+	}
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DeployDuration   = "deploy_duration_seconds"
+	DeploySuccess    = "deploy_success"
+	TeardownDuration = "teardown_duration_seconds"
+)
+
+var help = map[string]string{
+	DeployDuration:   "Time taken by the last preview deploy.",
+	DeploySuccess:    "Whether the last preview deploy succeeded (1) or failed (0).",
+	TeardownDuration: "Time taken by the last preview teardown.",
+}
+
+type Sample struct {
+	Name  string
+	Value float64
+}
+
+func Duration(name string, d time.Duration) Sample {
+	return Sample{Name: name, Value: d.Seconds()}
+}
+
+func Success(ok bool) Sample {
+	if ok {
+		return Sample{Name: DeploySuccess, Value: 1}
+	}
+	return Sample{Name: DeploySuccess, Value: 0}
+}
+
+// WriteFile replaces path with the samples in the Prometheus text format.
+// The file is written next to path and renamed, so a scraper never reads a
+// partial file.
+func WriteFile(path string, labels map[string]string, samples ...Sample) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create metrics file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Write(tmp, labels, samples...); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	return nil
+}
+
+func Write(w io.Writer, labels map[string]string, samples ...Sample) error {
+	formatted := formatLabels(labels)
+
+	var sb strings.Builder
+	for _, s := range samples {
+		if text, ok := help[s.Name]; ok {
+			fmt.Fprintf(&sb, "# HELP %s %s\n", s.Name, text)
+		}
+		fmt.Fprintf(&sb, "# TYPE %s gauge\n", s.Name)
+		fmt.Fprintf(&sb, "%s%s %s\n", s.Name, formatted, strconv.FormatFloat(s.Value, 'g', -1, 64))
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, k, labelEscaper.Replace(labels[k])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	sampleLine  = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{([a-zA-Z_][a-zA-Z0-9_]*="(\\.|[^"\\])*",?)*\})? (\S+)$`)
+	commentLine = regexp.MustCompile(`^# (HELP|TYPE) ([a-zA-Z_:][a-zA-Z0-9_:]*) .+$`)
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	err := Write(&sb, map[string]string{"repo": "app", "owner": "acme", "pr": "42"},
+		Duration(DeployDuration, 1500*time.Millisecond),
+		Success(true),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `# HELP deploy_duration_seconds Time taken by the last preview deploy.
+# TYPE deploy_duration_seconds gauge
+deploy_duration_seconds{owner="acme",pr="42",repo="app"} 1.5
+# HELP deploy_success Whether the last preview deploy succeeded (1) or failed (0).
+# TYPE deploy_success gauge
+deploy_success{owner="acme",pr="42",repo="app"} 1
+`
+	if sb.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", sb.String(), want)
+	}
+}
+
+func TestWrite_Parses(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	err := Write(&sb, map[string]string{"owner": "acme", "repo": `odd"name\with` + "\nnewline", "branch": "feature/login"},
+		Duration(TeardownDuration, 90*time.Second),
+		Success(false),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	samples := 0
+	for _, line := range lines {
+		if commentLine.MatchString(line) {
+			continue
+		}
+		if !sampleLine.MatchString(line) {
+			t.Errorf("line does not parse as a sample: %q", line)
+			continue
+		}
+		samples++
+	}
+	if samples != 2 {
+		t.Errorf("expected 2 samples, got %d", samples)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "draftdeploy.prom")
+	if err := os.WriteFile(path, []byte("stale\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(path, nil, Success(false)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), "deploy_success 0\n") {
+		t.Errorf("unexpected file contents:\n%s", data)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected temp file to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestWriteFile_MissingDir(t *testing.T) {
+	t.Parallel()
+
+	if err := WriteFile(filepath.Join(t.TempDir(), "missing", "draftdeploy.prom"), nil, Success(true)); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
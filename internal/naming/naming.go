@@ -0,0 +1,172 @@
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultResourceGroupPrefix = "draftdeploy-"
+	DefaultShortPrefix         = "dd-"
+	DefaultTemplate            = "{owner}-{repo}-{ref}"
+
+	MaxResourceGroupLen = 90
+	MaxDNSLabelLen      = 63
+	MinDNSLabelLen      = 3
+	MaxDomainLen        = 253
+	maxBranchSlugLen    = 40
+	repoHashLen         = 8
+	branchHashLen       = 6
+	branchRefPrefix     = "br-"
+)
+
+var (
+	invalidResourceGroupChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+	invalidDNSChars           = regexp.MustCompile(`[^a-z0-9-]`)
+	domainLabel               = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+)
+
+type Target struct {
+	PRNumber int
+	Branch   string
+}
+
+// Ref identifies the target in resource names. Branch refs start with
+// "br-" so a branch named pr12 never takes over PR 12's preview.
+func (t Target) Ref() string {
+	if t.Branch != "" {
+		return branchRefPrefix + BranchID(t.Branch)
+	}
+	return fmt.Sprintf("pr%d", t.PRNumber)
+}
+
+func (t Target) PR() string {
+	if t.Branch != "" {
+		return t.Ref()
+	}
+	return strconv.Itoa(t.PRNumber)
+}
+
+type Scheme struct {
+	ResourceGroupPrefix string
+	ShortPrefix         string
+	Template            string
+}
+
+func DefaultScheme() Scheme {
+	return Scheme{
+		ResourceGroupPrefix: DefaultResourceGroupPrefix,
+		ShortPrefix:         DefaultShortPrefix,
+		Template:            DefaultTemplate,
+	}
+}
+
+func (s Scheme) Validate() error {
+	if !strings.Contains(s.Template, "{pr}") && !strings.Contains(s.Template, "{ref}") {
+		return fmt.Errorf("template %q must contain {pr} or {ref}", s.Template)
+	}
+	return nil
+}
+
+func (s Scheme) ResourceGroupName(owner, repo string, target Target) (string, error) {
+	name := invalidResourceGroupChars.ReplaceAllString(s.ResourceGroupPrefix+s.render(owner, repo, target), "-")
+
+	if len(name) > MaxResourceGroupLen {
+		return "", fmt.Errorf("resource group name too long: %d chars (max %d)", len(name), MaxResourceGroupLen)
+	}
+	return name, nil
+}
+
+func (s Scheme) DNSLabel(owner, repo string, target Target) (string, error) {
+	label := sanitizeDNS(s.ShortPrefix + s.render(owner, repo, target))
+
+	if len(label) > MaxDNSLabelLen {
+		label = s.ContainerGroupName(owner, repo, target)
+	}
+	if len(label) < MinDNSLabelLen {
+		return "", fmt.Errorf("DNS label too short: %d chars (min %d)", len(label), MinDNSLabelLen)
+	}
+	return label, nil
+}
+
+// ContainerGroupName includes a short hash of owner/repo, so the same PR
+// number in different repositories never yields the same name.
+func (s Scheme) ContainerGroupName(owner, repo string, target Target) string {
+	return truncate(sanitizeDNS(s.ShortPrefix+repoHash(owner, repo)+"-"+target.Ref()), MaxDNSLabelLen)
+}
+
+// LegacyContainerGroupName is the name used before the repository hash was
+// added, kept so existing previews can be replaced cleanly.
+func (s Scheme) LegacyContainerGroupName(target Target) string {
+	return truncate(sanitizeDNS(s.ShortPrefix+target.Ref()), MaxDNSLabelLen)
+}
+
+func repoHash(owner, repo string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(owner + "/" + repo)))
+	return hex.EncodeToString(sum[:])[:repoHashLen]
+}
+
+func CustomDomain(template string, target Target) (string, error) {
+	domain := strings.ToLower(strings.NewReplacer(
+		"{pr}", target.PR(),
+		"{ref}", target.Ref(),
+	).Replace(strings.TrimSpace(template)))
+
+	if err := ValidateDomain(domain); err != nil {
+		return "", err
+	}
+	return domain, nil
+}
+
+func ValidateDomain(domain string) error {
+	if len(domain) > MaxDomainLen {
+		return fmt.Errorf("domain %q too long: %d chars (max %d)", domain, len(domain), MaxDomainLen)
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return fmt.Errorf("domain %q must have at least two labels", domain)
+	}
+	for _, label := range labels {
+		if !domainLabel.MatchString(label) {
+			return fmt.Errorf("domain %q has invalid label %q", domain, label)
+		}
+	}
+	return nil
+}
+
+func BranchSlug(branch string) string {
+	return truncate(sanitizeDNS(branch), maxBranchSlugLen)
+}
+
+// BranchID is the branch slug followed by a short hash of the raw branch
+// name, so branches that slug the same (feature/a and feature-a, or long
+// names cut at the same point) still get their own preview.
+func BranchID(branch string) string {
+	sum := sha256.Sum256([]byte(branch))
+	return BranchSlug(branch) + "-" + hex.EncodeToString(sum[:])[:branchHashLen]
+}
+
+func (s Scheme) render(owner, repo string, target Target) string {
+	return strings.NewReplacer(
+		"{owner}", owner,
+		"{repo}", repo,
+		"{pr}", target.PR(),
+		"{ref}", target.Ref(),
+	).Replace(s.Template)
+}
+
+func sanitizeDNS(name string) string {
+	return strings.Trim(invalidDNSChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func truncate(name string, maxLen int) string {
+	if len(name) > maxLen {
+		name = strings.TrimRight(name[:maxLen], "-")
+	}
+	return name
+}
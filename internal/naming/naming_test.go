@@ -0,0 +1,241 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResourceGroupName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		scheme  Scheme
+		owner   string
+		repo    string
+		target  Target
+		want    string
+		wantErr bool
+	}{
+		{"pull request", DefaultScheme(), "acme", "app", Target{PRNumber: 12}, "draftdeploy-acme-app-pr12", false},
+		{"keeps case and dots", DefaultScheme(), "Acme", "My.App", Target{PRNumber: 7}, "draftdeploy-Acme-My.App-pr7", false},
+		{"unicode owner", DefaultScheme(), "zoë", "app", Target{PRNumber: 1}, "draftdeploy-zo--app-pr1", false},
+		{"branch", DefaultScheme(), "acme", "app", Target{Branch: "feature/Login"}, "draftdeploy-acme-app-br-feature-login-48fad0", false},
+		{"custom template", Scheme{ResourceGroupPrefix: "team-x-", Template: "{repo}-{pr}"}, "acme", "app", Target{PRNumber: 3}, "team-x-app-3", false},
+		{"at limit", DefaultScheme(), "a", strings.Repeat("r", 71), Target{PRNumber: 1}, "draftdeploy-a-" + strings.Repeat("r", 71) + "-pr1", false},
+		{"too long", DefaultScheme(), "acme", strings.Repeat("r", 80), Target{PRNumber: 1}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.scheme.ResourceGroupName(tt.owner, tt.repo, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResourceGroupName() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResourceGroupName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDNSLabel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		scheme  Scheme
+		owner   string
+		repo    string
+		target  Target
+		want    string
+		wantErr bool
+	}{
+		{"pull request", DefaultScheme(), "Acme", "My.App", Target{PRNumber: 7}, "dd-acme-my-app-pr7", false},
+		{"unicode owner", DefaultScheme(), "Zoë", "app", Target{PRNumber: 1}, "dd-zo--app-pr1", false},
+		{"branch", DefaultScheme(), "acme", "app", Target{Branch: "Feature/Login_Page"}, "dd-acme-app-br-feature-login-page-12ff04", false},
+		{"long repo falls back", DefaultScheme(), "acme", strings.Repeat("r", 60), Target{PRNumber: 12}, "dd-b73fd90c-pr12", false},
+		{"trims dashes", Scheme{ShortPrefix: "-", Template: "{owner}-{ref}-"}, "acme", "app", Target{PRNumber: 5}, "acme-pr5", false},
+		{"too short after trimming", Scheme{ShortPrefix: "-", Template: "-{pr}-"}, "acme", "app", Target{PRNumber: 5}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.scheme.DNSLabel(tt.owner, tt.repo, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DNSLabel() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DNSLabel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainerGroupName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		scheme Scheme
+		owner  string
+		repo   string
+		target Target
+		want   string
+	}{
+		{"pull request", DefaultScheme(), "acme", "app", Target{PRNumber: 12}, "dd-5f89da04-pr12"},
+		{"same PR in another repo", DefaultScheme(), "acme", "other", Target{PRNumber: 12}, "dd-5095472c-pr12"},
+		{"repo case ignored", DefaultScheme(), "ACME", "App", Target{PRNumber: 12}, "dd-5f89da04-pr12"},
+		{"long repo", DefaultScheme(), "acme", strings.Repeat("r", 60), Target{PRNumber: 12}, "dd-b73fd90c-pr12"},
+		{"branch", DefaultScheme(), "acme", "app", Target{Branch: "fix/Bug_42"}, "dd-5f89da04-br-fix-bug-42-aee056"},
+		{"custom prefix", Scheme{ShortPrefix: "Team_X-"}, "acme", "app", Target{PRNumber: 3}, "team-x-5f89da04-pr3"},
+		{"long prefix truncated", Scheme{ShortPrefix: strings.Repeat("p", 70)}, "acme", "app", Target{PRNumber: 3}, strings.Repeat("p", MaxDNSLabelLen)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.scheme.ContainerGroupName(tt.owner, tt.repo, tt.target)
+			if got != tt.want {
+				t.Errorf("ContainerGroupName() = %q, want %q", got, tt.want)
+			}
+			if again := tt.scheme.ContainerGroupName(tt.owner, tt.repo, tt.target); again != got {
+				t.Errorf("ContainerGroupName() not stable: %q then %q", got, again)
+			}
+			if len(got) > MaxDNSLabelLen {
+				t.Errorf("ContainerGroupName() = %q is longer than %d", got, MaxDNSLabelLen)
+			}
+		})
+	}
+}
+
+func TestContainerGroupName_LongBranch(t *testing.T) {
+	t.Parallel()
+
+	got := DefaultScheme().ContainerGroupName(strings.Repeat("o", 39), strings.Repeat("r", 100), Target{Branch: strings.Repeat("feature-", 20)})
+	if len(got) > MaxDNSLabelLen {
+		t.Errorf("ContainerGroupName() = %q is longer than %d", got, MaxDNSLabelLen)
+	}
+	if !strings.HasPrefix(got, "dd-") || strings.HasSuffix(got, "-") {
+		t.Errorf("ContainerGroupName() = %q is not a valid name", got)
+	}
+}
+
+func TestLegacyContainerGroupName(t *testing.T) {
+	t.Parallel()
+
+	if got := DefaultScheme().LegacyContainerGroupName(Target{PRNumber: 12}); got != "dd-pr12" {
+		t.Errorf("LegacyContainerGroupName() = %q, want %q", got, "dd-pr12")
+	}
+}
+
+func TestTargetRef_BranchesDoNotCollide(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("feature-", 6)
+	pairs := []struct {
+		name string
+		a, b Target
+	}{
+		{"branch named like a PR", Target{Branch: "pr12"}, Target{PRNumber: 12}},
+		{"same slug", Target{Branch: "feature/a"}, Target{Branch: "feature-a"}},
+		{"same after truncation", Target{Branch: long + "one"}, Target{Branch: long + "two"}},
+	}
+
+	for _, tt := range pairs {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			scheme := DefaultScheme()
+			if tt.a.Ref() == tt.b.Ref() {
+				t.Errorf("Ref() = %q for both targets", tt.a.Ref())
+			}
+			if a, b := scheme.ContainerGroupName("acme", "app", tt.a), scheme.ContainerGroupName("acme", "app", tt.b); a == b {
+				t.Errorf("ContainerGroupName() = %q for both targets", a)
+			}
+			a, errA := scheme.DNSLabel("acme", "app", tt.a)
+			b, errB := scheme.DNSLabel("acme", "app", tt.b)
+			if errA != nil || errB != nil || a == b {
+				t.Errorf("DNSLabel() = %q, %q (errors %v, %v), want distinct labels", a, b, errA, errB)
+			}
+		})
+	}
+}
+
+func TestBranchSlug(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{"main", "main"},
+		{"Feature/Login_Page", "feature-login-page"},
+		{"---", ""},
+		{strings.Repeat("a", 39) + "-" + strings.Repeat("b", 20), strings.Repeat("a", 39)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			t.Parallel()
+
+			if got := BranchSlug(tt.branch); got != tt.want {
+				t.Errorf("BranchSlug(%q) = %q, want %q", tt.branch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemeValidate(t *testing.T) {
+	t.Parallel()
+
+	if err := DefaultScheme().Validate(); err != nil {
+		t.Errorf("expected default scheme to be valid, got %v", err)
+	}
+	if err := (Scheme{Template: "{owner}-{pr}"}).Validate(); err != nil {
+		t.Errorf("expected {pr} template to be valid, got %v", err)
+	}
+	if err := (Scheme{Template: "{repo}"}).Validate(); err == nil {
+		t.Error("expected template without {pr} or {ref} to be rejected")
+	}
+}
+
+func TestCustomDomain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		template string
+		target   Target
+		want     string
+		wantErr  bool
+	}{
+		{"pull request", "pr-{pr}.preview.example.com", Target{PRNumber: 12}, "pr-12.preview.example.com", false},
+		{"ref placeholder", "{ref}.Preview.Example.com", Target{PRNumber: 3}, "pr3.preview.example.com", false},
+		{"branch", "{ref}.preview.example.com", Target{Branch: "feature/Login"}, "br-feature-login-48fad0.preview.example.com", false},
+		{"single label", "preview-{pr}", Target{PRNumber: 1}, "", true},
+		{"invalid characters", "pr_{pr}.example.com", Target{PRNumber: 1}, "", true},
+		{"leading dash", "-{pr}.example.com", Target{PRNumber: 1}, "", true},
+		{"empty label", "pr-{pr}..example.com", Target{PRNumber: 1}, "", true},
+		{"label too long", strings.Repeat("a", 64) + ".example.com", Target{PRNumber: 1}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := CustomDomain(tt.template, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CustomDomain() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CustomDomain() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
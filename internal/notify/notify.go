@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-DraftDeploy-Signature"
+	EventHeader     = "X-DraftDeploy-Event"
+
+	EventDeployStarted   = "deploy.started"
+	EventDeploySucceeded = "deploy.succeeded"
+	EventDeployFailed    = "deploy.failed"
+	EventTornDown        = "teardown.completed"
+
+	requestTimeout = 10 * time.Second
+)
+
+type Event struct {
+	Type            string    `json:"type"`
+	Repository      string    `json:"repository"`
+	PR              int       `json:"pr,omitempty"`
+	Title           string    `json:"title,omitempty"`
+	Branch          string    `json:"branch,omitempty"`
+	ResourceGroup   string    `json:"resource_group"`
+	FQDN            string    `json:"fqdn,omitempty"`
+	URL             string    `json:"url,omitempty"`
+	DurationSeconds float64   `json:"duration_seconds,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+// Formatter turns an event into a request body. A nil body means the
+// event is not sent.
+type Formatter func(Event) ([]byte, error)
+
+type Notifier struct {
+	url    string
+	secret []byte
+	format Formatter
+	client *http.Client
+}
+
+func NewNotifier(url, secret string) *Notifier {
+	return &Notifier{
+		url:    url,
+		secret: []byte(secret),
+		format: formatJSON,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (n *Notifier) Notify(ctx context.Context, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	body, err := n.format(event)
+	if err != nil {
+		return fmt.Errorf("failed to format %s event: %w", event.Type, err)
+	}
+	if body == nil {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event.Type)
+	if len(n.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(n.secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func formatJSON(event Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// Sign returns the signature sent in SignatureHeader: "sha256=" followed by
+// the hex HMAC-SHA256 of the body, the same scheme GitHub webhooks use.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	t.Parallel()
+
+	var (
+		body      []byte
+		signature string
+		eventType string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected JSON content type, got %q", ct)
+		}
+		signature = r.Header.Get(SignatureHeader)
+		eventType = r.Header.Get(EventHeader)
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	event := Event{
+		Type:            EventDeploySucceeded,
+		Repository:      "acme/app",
+		PR:              42,
+		ResourceGroup:   "draftdeploy-acme-app-pr42",
+		FQDN:            "dd-acme-app-pr42.eastus.azurecontainer.io",
+		URL:             "http://dd-acme-app-pr42.eastus.azurecontainer.io",
+		DurationSeconds: 93,
+		Timestamp:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if err := NewNotifier(server.URL, "s3cret").Notify(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("invalid JSON payload: %v", err)
+	}
+	want := map[string]any{
+		"type":             "deploy.succeeded",
+		"repository":       "acme/app",
+		"pr":               float64(42),
+		"resource_group":   "draftdeploy-acme-app-pr42",
+		"fqdn":             "dd-acme-app-pr42.eastus.azurecontainer.io",
+		"url":              "http://dd-acme-app-pr42.eastus.azurecontainer.io",
+		"duration_seconds": float64(93),
+		"timestamp":        "2024-01-02T03:04:05Z",
+	}
+	if len(payload) != len(want) {
+		t.Errorf("expected %d fields, got %v", len(want), payload)
+	}
+	for k, v := range want {
+		if payload[k] != v {
+			t.Errorf("payload[%q] = %v, want %v", k, payload[k], v)
+		}
+	}
+
+	if eventType != EventDeploySucceeded {
+		t.Errorf("expected event header %q, got %q", EventDeploySucceeded, eventType)
+	}
+	if signature != Sign([]byte("s3cret"), body) {
+		t.Errorf("signature %q does not match body", signature)
+	}
+}
+
+func TestNotify_Unsigned(t *testing.T) {
+	t.Parallel()
+
+	var signed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, signed = r.Header[SignatureHeader]
+	}))
+	t.Cleanup(server.Close)
+
+	if err := NewNotifier(server.URL, "").Notify(context.Background(), Event{Type: EventDeployStarted}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if signed {
+		t.Error("expected no signature header without a secret")
+	}
+}
+
+func TestNotify_ErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	if err := NewNotifier(server.URL, "").Notify(context.Background(), Event{Type: EventDeployFailed}); err == nil {
+		t.Error("expected error for non-2xx response")
+	}
+}
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	got := Sign([]byte("It's a Secret to Everybody"), []byte("Hello, World!"))
+	want := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+	if got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// NewSlackNotifier posts preview links to a Slack incoming webhook. Teams
+// workflow webhooks accept the same payload.
+func NewSlackNotifier(url string) *Notifier {
+	return &Notifier{
+		url:    url,
+		format: formatSlack,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func formatSlack(event Event) ([]byte, error) {
+	var text string
+	switch event.Type {
+	case EventDeploySucceeded:
+		text = fmt.Sprintf(":rocket: Preview for %s is live: <%s>", slackSubject(event), event.URL)
+	case EventTornDown:
+		text = fmt.Sprintf(":wastebasket: Preview for %s was torn down", slackSubject(event))
+	default:
+		return nil, nil
+	}
+	return json.Marshal(slackMessage{Text: text})
+}
+
+func slackSubject(event Event) string {
+	var sb strings.Builder
+	sb.WriteString(slackEscape(event.Repository))
+	switch {
+	case event.PR != 0:
+		fmt.Fprintf(&sb, "#%d", event.PR)
+	case event.Branch != "":
+		fmt.Fprintf(&sb, " (%s)", slackEscape(event.Branch))
+	}
+	if event.Title != "" {
+		fmt.Fprintf(&sb, " _%s_", slackEscape(event.Title))
+	}
+	return sb.String()
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func slackEscape(s string) string {
+	return slackEscaper.Replace(s)
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFormatSlack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		event Event
+		want  string
+	}{
+		{
+			name:  "deploy succeeded",
+			event: Event{Type: EventDeploySucceeded, Repository: "acme/app", PR: 42, Title: "Add <login> & signup", URL: "http://dd-acme-app-pr42.eastus.azurecontainer.io"},
+			want:  ":rocket: Preview for acme/app#42 _Add &lt;login&gt; &amp; signup_ is live: <http://dd-acme-app-pr42.eastus.azurecontainer.io>",
+		},
+		{
+			name:  "branch preview",
+			event: Event{Type: EventDeploySucceeded, Repository: "acme/app", Branch: "feature/login", URL: "http://preview.example.com"},
+			want:  ":rocket: Preview for acme/app (feature/login) is live: <http://preview.example.com>",
+		},
+		{
+			name:  "torn down",
+			event: Event{Type: EventTornDown, Repository: "acme/app", PR: 42},
+			want:  ":wastebasket: Preview for acme/app#42 was torn down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			body, err := formatSlack(tt.event)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var msg slackMessage
+			if err := json.Unmarshal(body, &msg); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if msg.Text != tt.want {
+				t.Errorf("text = %q, want %q", msg.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlackNotifier_SkipsOtherEvents(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	t.Cleanup(server.Close)
+
+	notifier := NewSlackNotifier(server.URL)
+	for _, eventType := range []string{EventDeployStarted, EventDeployFailed, EventDeploySucceeded} {
+		if err := notifier.Notify(context.Background(), Event{Type: eventType, Repository: "acme/app", PR: 1}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected only the succeeded event to be posted, got %d requests", calls)
+	}
+}